@@ -0,0 +1,136 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Bind defines a flag for each exported field of the struct pointed to by v.
+// The value of each flag is stored in its field and the value of the field at
+// the time of the call is the default value of the flag. Fields are
+// configured with struct tags:
+//
+//	Port int `flag:"port,p" usage:"listen port" env:"PORT" default:"8080"`
+//	Region string `required:"true"`
+//
+// The flag tag holds the name and the optional shorthand of the flag. Without
+// a name the name is derived from the field name, e.g. LogLevel defines the
+// flag log-level, and the tag "-" skips the field. The default tag overrides
+// the value of the field as the default value.
+//
+// Fields of struct types that are not flag values themselves define the flags
+// of their fields prefixed with the name of the field, e.g. db-host for the
+// field Host in the field DB. Fields of embedded structs are defined without
+// a prefix. Bind panics if v is not a pointer to a struct, or if a field has
+// a type that cannot be used as a flag.
+func Bind[C any](fs *FlagSet[C], v any) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("cannot bind %T: expected a pointer to a struct", v))
+	}
+	bindStruct(fs.untyped(), rv.Elem(), "")
+}
+
+func bindStruct(fs *FlagSet[any], rv reflect.Value, prefix string) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf, field := t.Field(i), rv.Field(i)
+		tag, hasTag := sf.Tag.Lookup("flag")
+		nested := isNested(fs, sf.Type)
+		// Fields of embedded structs are promoted even if the struct is not
+		// exported
+		if tag == "-" || !sf.IsExported() && !(sf.Anonymous && nested) {
+			continue
+		}
+
+		name, shorthand, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = kebabCase(sf.Name)
+		}
+
+		if nested {
+			if sf.Anonymous && !hasTag {
+				bindStruct(fs, field, prefix)
+			} else {
+				bindStruct(fs, field, prefix+name+"-")
+			}
+			continue
+		}
+		name = prefix + name
+
+		var opts []Option
+		if env := sf.Tag.Get("env"); env != "" {
+			opts = append(opts, Env(env))
+		}
+		if sf.Tag.Get("required") == "true" {
+			opts = append(opts, Required())
+		}
+
+		usage := sf.Tag.Get("usage")
+		defineVar(fs, field.Addr().Interface(), name, shorthand, usage, opts...)
+
+		if def, ok := sf.Tag.Lookup("default"); ok {
+			setDefault(fs, name, def)
+		}
+	}
+}
+
+// isNested reports whether t is a struct type whose fields define flags
+// instead of the field itself.
+func isNested(fs *FlagSet[any], t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if _, ok := lookupTypeOf(fs, t); ok {
+		return false
+	}
+	p := reflect.PointerTo(t)
+	return !p.Implements(typeOf[flag.Value]()) && !p.Implements(typeOf[encoding.TextUnmarshaler]())
+}
+
+// setDefault sets the default value of the flag from its string form.
+func setDefault(fs *FlagSet[any], name string, value string) {
+	f := fs.Lookup(name)
+	if f == nil {
+		return
+	}
+	if err := f.Value.Set(value); err != nil {
+		panic(fmt.Sprintf("invalid default value %q for flag %s: %v", value, name, err))
+	}
+	// The default value of slices and maps is replaced on the first Set
+	if r, ok := f.Value.(resetter); ok {
+		r.reset()
+	}
+
+	f.DefValue = f.Value.String()
+	if info := fs.lookupInfo(name); info != nil && info.Shorthand != "" {
+		if s := fs.Lookup(info.Shorthand); s != nil {
+			s.DefValue = f.DefValue
+		}
+	}
+}
+
+// kebabCase converts a field name to a flag name, e.g. LogLevel to log-level
+// and HTTPPort to http-port.
+func kebabCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,208 @@
+package miniflag
+
+import (
+	"io"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type bindDB struct {
+	Host    string        `usage:"database host"`
+	Port    int           `default:"5432"`
+	Timeout time.Duration `flag:"timeout,t" default:"5s"`
+}
+
+type bindCommon struct {
+	Verbose Counter `flag:",v"`
+}
+
+type bindConfig struct {
+	bindCommon
+	Name     string            `flag:"name,n" usage:"service name"`
+	Port     int               `flag:"port,p" env:"BIND_PORT" default:"8080"`
+	LogLevel string            `default:"info"`
+	HTTPAddr netip.Addr        `flag:"http-addr"`
+	Tags     []string          `flag:"tag" default:"a,b"`
+	Labels   map[string]string `flag:"label"`
+	URL      *url.URL
+	DB       bindDB
+	Skipped  string `flag:"-"`
+	internal string
+}
+
+func TestBind(t *testing.T) {
+	RegisterType(url.Parse, func(u *url.URL) string { return u.String() })
+	t.Cleanup(func() { delete(types, typeOf[*url.URL]()) })
+	t.Setenv("BIND_PORT", "9090")
+
+	fs := NewFlagSet("", ContinueOnError)
+	cfg := bindConfig{Name: "default", URL: &url.URL{}}
+	Bind(fs, &cfg)
+
+	args := []string{
+		"-vv",
+		"--http-addr", "127.0.0.1",
+		"--tag", "c",
+		"--label", "env=prod",
+		"--url", "https://example.com",
+		"--db-host", "db",
+		"-t", "1s",
+	}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := bindConfig{
+		bindCommon: bindCommon{Verbose: 2},
+		Name:       "default",
+		Port:       9090,
+		LogLevel:   "info",
+		HTTPAddr:   netip.MustParseAddr("127.0.0.1"),
+		Tags:       []string{"c"},
+		Labels:     map[string]string{"env": "prod"},
+		URL:        &url.URL{Scheme: "https", Host: "example.com"},
+		DB:         bindDB{Host: "db", Port: 5432, Timeout: time.Second},
+	}
+	if !reflect.DeepEqual(expected, cfg) {
+		t.Fatalf("config did not match expected %+v, got %+v", expected, cfg)
+	}
+
+	for _, name := range []string{"skipped", "internal", "db", "bind-common"} {
+		if fs.Lookup(name) != nil {
+			t.Fatalf("expected no flag %s", name)
+		}
+	}
+	if f := fs.Lookup("db-port"); f == nil || f.DefValue != "5432" {
+		t.Fatalf("expected flag db-port with default value 5432, got %v", f)
+	}
+}
+
+func TestBindConfig(t *testing.T) {
+	type config struct {
+		Labels map[string]string `flag:"label"`
+		DB     bindDB
+		Cache  struct {
+			Redis struct {
+				Addr string
+			}
+		}
+	}
+
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{
+			file: "config.toml",
+			content: `
+label = { env = "prod" }
+
+[db]
+host = "db"
+timeout = "1s"
+
+[cache.redis]
+addr = "localhost:6379"
+`,
+		},
+		{
+			file: "config.toml",
+			content: `
+label = { env = "prod" }
+db.host = "db"
+db.timeout = "1s"
+cache.redis.addr = "localhost:6379"
+`,
+		},
+		{
+			file: "config.yaml",
+			content: `
+label:
+  env: prod
+db:
+  host: db
+  timeout: 1s
+cache:
+  redis:
+    addr: localhost:6379
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run(tt.file, func(t *testing.T) {
+			var cfg config
+			Bind(fs, &cfg)
+			fs.SetConfigFile(writeConfig(t, tt.file, tt.content))
+
+			if err := fs.Parse(nil); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := config{
+				Labels: map[string]string{"env": "prod"},
+				DB:     bindDB{Host: "db", Port: 5432, Timeout: time.Second},
+			}
+			expected.Cache.Redis.Addr = "localhost:6379"
+			if !reflect.DeepEqual(expected, cfg) {
+				t.Fatalf("config did not match expected %+v, got %+v", expected, cfg)
+			}
+			if source := fs.Source("db-host"); source != SourceConfig {
+				t.Fatalf("source did not match expected %s, got %s", SourceConfig, source)
+			}
+		})
+	}
+}
+
+func TestBindPanics(t *testing.T) {
+	tests := []struct {
+		value any
+	}{
+		{value: bindDB{}},
+		{value: new(int)},
+		{value: &struct{ C chan int }{}},
+		{value: &struct{ A any }{}},
+		{value: &struct {
+			Port int `default:"http"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			Bind(fs, tt.value)
+		})
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Port", expected: "port"},
+		{name: "LogLevel", expected: "log-level"},
+		{name: "HTTPPort", expected: "http-port"},
+		{name: "DB", expected: "db"},
+		{name: "IPv6", expected: "i-pv6"},
+		{name: "Retry2Times", expected: "retry2-times"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := kebabCase(tt.name); tt.expected != actual {
+				t.Fatalf("name did not match expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
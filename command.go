@@ -0,0 +1,325 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Command is a command in a tree of commands, such as remote and add in
+// tool remote add origin. Each command has its own flag set and handler.
+type Command struct {
+	// Name is the name the command is invoked with.
+	Name string
+	// Aliases are alternative names the command can be invoked with, e.g. rm
+	// for remove.
+	Aliases []string
+	// Short is a one line description shown in the command list of the parent.
+	Short string
+	// Long is the description shown in the help usage of the command.
+	Long string
+	// Flags holds the flags of the command.
+	Flags *FlagSet[any]
+	// Run is called with the non-flag arguments when the command is executed.
+	Run func(ctx context.Context, args []string) error
+
+	parent   *Command
+	children []*Command
+	// prefixMatching is set when subcommands can be invoked with a unique
+	// prefix of their name
+	prefixMatching bool
+}
+
+// AmbiguousCommandError is the error returned by Parse when an argument is a
+// prefix of the names of more than one subcommand.
+type AmbiguousCommandError struct {
+	Command    string
+	Candidates []string
+}
+
+func (e *AmbiguousCommandError) Error() string {
+	return "command " + e.Command + " is ambiguous: " + strings.Join(e.Candidates, ", ")
+}
+
+// commandLine is the command of CommandLine. Flag sets created with
+// NewFlagSet are its subcommands, which are dispatched to by Parse.
+var commandLine = newRootCommand(CommandLine)
+
+func newRootCommand(fs *FlagSet[any]) *Command {
+	c := &Command{Name: fs.Name(), Flags: fs}
+	fs.command = c
+	return c
+}
+
+// NewCommand returns a new command with the given name, short description and
+// handler. The flags of the command are defined to its Flags with SetFlag.
+func NewCommand(name string, short string, run func(ctx context.Context, args []string) error) *Command {
+	fs := newFlagSet(name, ContinueOnError)
+	c := &Command{Name: name, Short: short, Flags: fs, Run: run}
+	fs.command = c
+	return c
+}
+
+// AddCommand adds subcommands to the command.
+func (c *Command) AddCommand(children ...*Command) {
+	for _, child := range children {
+		child.parent = c
+		child.Flags.parent = c.Flags
+		c.children = append(c.children, child)
+	}
+}
+
+// Commands returns the subcommands of the command.
+func (c *Command) Commands() []*Command {
+	return c.children
+}
+
+// SetPrefixMatching sets whether subcommands can be invoked with a unique
+// prefix of their names or aliases, e.g. sta for status. The setting applies
+// to the subcommands of the command and their subcommands in turn. A prefix
+// of more than one subcommand is an AmbiguousCommandError.
+func (c *Command) SetPrefixMatching(enabled bool) {
+	c.prefixMatching = enabled
+}
+
+// Command returns the subcommand with the given name or alias, or a unique
+// prefix of them if prefix matching is enabled. Command returns nil if there
+// is no such subcommand or the prefix is ambiguous.
+func (c *Command) Command(name string) *Command {
+	child, _ := c.lookup(name)
+	return child
+}
+
+// lookup returns the subcommand with the given name, alias or prefix, or an
+// AmbiguousCommandError if the prefix matches more than one subcommand.
+func (c *Command) lookup(name string) (*Command, error) {
+	for _, child := range c.children {
+		if child.hasName(name) {
+			return child, nil
+		}
+	}
+	if name == "" || !c.matchesPrefixes() {
+		return nil, nil
+	}
+
+	var matches []*Command
+	for _, child := range c.children {
+		for _, n := range child.names() {
+			if strings.HasPrefix(n, name) {
+				matches = append(matches, child)
+				break
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	}
+
+	candidates := make([]string, len(matches))
+	for i, m := range matches {
+		candidates[i] = m.Name
+	}
+	return nil, &AmbiguousCommandError{Command: name, Candidates: candidates}
+}
+
+// unknownCommand returns an error if the command has no handler and the
+// argument is likely a typo of the name of a subcommand, e.g. stauts for
+// status. Commands with a handler take the argument as is.
+func (c *Command) unknownCommand(arg string) error {
+	if c.Run != nil {
+		return nil
+	}
+
+	var names []string
+	for _, child := range c.children {
+		names = append(names, child.names()...)
+	}
+	if suggestion := suggest(arg, names); suggestion != "" {
+		return fmt.Errorf("unknown command %s for %s, did you mean %s?", arg, c.path(), suggestion)
+	}
+	return nil
+}
+
+// child returns the subcommand with the given name, ignoring aliases and
+// prefixes, or nil if there is no such subcommand.
+func (c *Command) child(name string) *Command {
+	for _, child := range c.children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// names returns the name and the aliases of the command.
+func (c *Command) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+func (c *Command) hasName(name string) bool {
+	for _, n := range c.names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPrefixes reports whether prefix matching is enabled for the command
+// or any of its parents.
+func (c *Command) matchesPrefixes() bool {
+	for ; c != nil; c = c.parent {
+		if c.prefixMatching {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses the argument list, which should not include the command name,
+// and returns the command to execute. The flags of each command in the path
+// are parsed in turn, until a non-flag argument is not a subcommand of the
+// command parsed last. The arguments of the returned command are available in
+// its Flags.Args. Persistent flags of a command are also parsed from the
+// arguments of its subcommands, and the flags of a command are read from the
+// environment and the config file after its subcommands have been parsed.
+// The required flags missing from any command in the path are reported
+// together in one RequiredFlagsError.
+func (c *Command) Parse(arguments []string) (*Command, error) {
+	cmd, err := c.parse(arguments)
+	if err != nil {
+		return cmd, err
+	}
+	return cmd, cmd.Flags.failed(c.check(cmd))
+}
+
+// parse parses the argument list like Parse, but leaves checking the required
+// flags and flag groups to Parse.
+func (c *Command) parse(arguments []string) (*Command, error) {
+	fs := c.Flags
+	fs.parsed = true
+	if len(c.children) == 0 {
+		_, err := fs.parseArgs(arguments)
+		if err == nil {
+			err = fs.parseSources()
+		}
+		return c, fs.failed(err)
+	}
+
+	// Parsing stops at the first non-flag argument, which may be a
+	// subcommand
+	interspersed := fs.interspersed
+	fs.interspersed = false
+	terminated, err := fs.parseArgs(arguments)
+	fs.interspersed = interspersed
+	if err != nil {
+		return c, fs.failed(err)
+	}
+
+	// The arguments after the terminator "--" are positionals as is
+	if terminated || fs.NArg() == 0 {
+		return c, fs.failed(fs.parseSources())
+	}
+
+	child, err := c.lookup(fs.Arg(0))
+	if err != nil {
+		return c, fs.failed(err)
+	}
+	if child != nil {
+		cmd, err := child.parse(fs.Args()[1:])
+		if err != nil {
+			return cmd, err
+		}
+		return cmd, fs.failed(fs.parseSources())
+	}
+	if err := c.unknownCommand(fs.Arg(0)); err != nil {
+		return c, fs.failed(err)
+	}
+
+	if interspersed {
+		first := fs.Arg(0)
+		_, err = fs.parseArgs(fs.Args()[1:])
+		fs.args = append([]string{first}, fs.args...)
+		if err != nil {
+			return c, fs.failed(err)
+		}
+	}
+	return c, fs.failed(fs.parseSources())
+}
+
+// check checks the required flags and flag groups of the commands in the path
+// from the command to its subcommand cmd. The missing required flags of all
+// the commands are returned in one RequiredFlagsError.
+func (c *Command) check(cmd *Command) error {
+	var path []*Command
+	for p := cmd; p != c.parent; p = p.parent {
+		path = append([]*Command{p}, path...)
+	}
+
+	var missing []string
+	for _, p := range path {
+		var requiredErr *RequiredFlagsError
+		if errors.As(p.Flags.checkRequired(), &requiredErr) {
+			missing = append(missing, requiredErr.Flags...)
+		}
+	}
+	if len(missing) > 0 {
+		return &RequiredFlagsError{Flags: missing}
+	}
+
+	for _, p := range path {
+		if err := p.Flags.checkGroups(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Execute parses the argument list like Parse and runs the handler of the
+// matched command with its non-flag arguments. A command without a handler
+// prints its help usage and returns an error, which names the first argument
+// as an unknown command if there is one.
+func (c *Command) Execute(ctx context.Context, arguments []string) error {
+	cmd, err := c.Parse(arguments)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Run == nil {
+		cmd.Flags.Usage()
+		if cmd.Flags.NArg() > 0 {
+			return fmt.Errorf("unknown command %s for %s", cmd.Flags.Arg(0), cmd.path())
+		}
+		return fmt.Errorf("command %s requires a subcommand", cmd.path())
+	}
+	return cmd.Run(ctx, cmd.Flags.Args())
+}
+
+// path returns the names of the command and its parents, e.g. tool remote.
+func (c *Command) path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.path() + " " + c.Name
+}
+
+// setCommand adds the subcommand, replacing a subcommand with the same name.
+func (c *Command) setCommand(child *Command) {
+	for i, existing := range c.children {
+		if existing.Name == child.Name {
+			c.children = append(c.children[:i], c.children[i+1:]...)
+			break
+		}
+	}
+	child.parent = c
+	child.Flags.parent = c.Flags
+	c.children = append(c.children, child)
+}
@@ -0,0 +1,411 @@
+package miniflag
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func newTestCommands() (root, remote, add *Command) {
+	root = NewCommand("tool", "", nil)
+	remote = NewCommand("remote", "Manage remotes", nil)
+	add = NewCommand("add", "Add a remote", func(ctx context.Context, args []string) error {
+		return nil
+	})
+	for _, c := range []*Command{root, remote, add} {
+		c.Flags.SetOutput(io.Discard)
+	}
+	remote.AddCommand(add)
+	root.AddCommand(remote)
+	return root, remote, add
+}
+
+func TestCommandParse(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		rest     []string
+		fetch    bool
+		x        bool
+	}{
+		{
+			args:     []string{},
+			expected: "tool",
+			rest:     []string{},
+		},
+		{
+			args:     []string{"remote"},
+			expected: "remote",
+			rest:     []string{},
+		},
+		{
+			args:     []string{"remote", "add", "origin", "url"},
+			expected: "add",
+			rest:     []string{"origin", "url"},
+		},
+		{
+			args:     []string{"remote", "add", "-f", "origin"},
+			expected: "add",
+			rest:     []string{"origin"},
+			fetch:    true,
+		},
+		{
+			args:     []string{"remote", "origin", "add"},
+			expected: "remote",
+			rest:     []string{"origin", "add"},
+		},
+		{
+			args:     []string{"pos", "-x"},
+			expected: "tool",
+			rest:     []string{"pos"},
+			x:        true,
+		},
+		{
+			args:     []string{"--", "remote"},
+			expected: "tool",
+			rest:     []string{"remote"},
+		},
+		{
+			args:     []string{"--", "pos", "-x"},
+			expected: "tool",
+			rest:     []string{"pos", "-x"},
+		},
+	}
+
+	for _, tt := range tests {
+		root, _, add := newTestCommands()
+		x := SetFlag(root.Flags, "x", "", false, "x flag")
+		fetch := SetFlag(add.Flags, "fetch", "f", false, "fetch flag")
+		t.Run("", func(t *testing.T) {
+			cmd, err := root.Parse(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Name != tt.expected {
+				t.Fatalf("command did not match expected %q, got %q", tt.expected, cmd.Name)
+			}
+			if !reflect.DeepEqual(tt.rest, cmd.Flags.Args()) {
+				t.Fatalf("arguments did not match expected %q, got %q", tt.rest, cmd.Flags.Args())
+			}
+			if *fetch != tt.fetch || *x != tt.x {
+				t.Fatalf("flag values did not match expected %t %t, got %t %t", tt.fetch, tt.x, *fetch, *x)
+			}
+		})
+	}
+}
+
+func TestCommandExecute(t *testing.T) {
+	root, _, add := newTestCommands()
+
+	var actual []string
+	add.Run = func(ctx context.Context, args []string) error {
+		actual = args
+		return nil
+	}
+
+	if err := root.Execute(context.Background(), []string{"remote", "add", "origin"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"origin"}; !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("arguments did not match expected %q, got %q", expected, actual)
+	}
+
+	expected := "command tool remote requires a subcommand"
+	if err := root.Execute(context.Background(), []string{"remote"}); err == nil || expected != err.Error() {
+		t.Fatalf("error did not match expected %q, got %v", expected, err)
+	}
+
+	expected = "unknown command origin for tool remote"
+	if err := root.Execute(context.Background(), []string{"remote", "origin"}); err == nil || expected != err.Error() {
+		t.Fatalf("error did not match expected %q, got %v", expected, err)
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	var b bytes.Buffer
+	root, remote, _ := newTestCommands()
+	remote.Long = "Manage the set of tracked repositories."
+	remote.AddCommand(&Command{Name: "remove", Aliases: []string{"rm", "delete"}, Short: "Remove a remote", Flags: newFlagSet("remove", ContinueOnError)})
+	remote.Flags.SetOutput(&b)
+	SetFlag(remote.Flags, "verbose", "v", false, "Usage for verbose")
+
+	if _, err := root.Parse([]string{"remote", "-h"}); err == nil {
+		t.Fatal("expected help error")
+	}
+
+	expected := `Manage the set of tracked repositories.
+
+usage: tool remote [-v --verbose] <command>
+    -v --verbose       Usage for verbose
+commands:
+    add                Add a remote
+    remove, rm, delete Remove a remote
+`
+	if actual := b.String(); expected != actual {
+		t.Fatalf("Help string did not match expected %q, got %q", expected, actual)
+	}
+}
+
+func TestPersistentFlags(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		verbose  bool
+		err      string
+	}{
+		{
+			args:     []string{"-v", "remote", "add", "origin"},
+			expected: "add",
+			verbose:  true,
+		},
+		{
+			args:     []string{"remote", "-v", "add", "origin"},
+			expected: "add",
+			verbose:  true,
+		},
+		{
+			args:     []string{"remote", "add", "origin", "--verbose"},
+			expected: "add",
+			verbose:  true,
+		},
+		{
+			args:     []string{"remote", "add", "-fv", "origin"},
+			expected: "add",
+			verbose:  true,
+		},
+		{
+			args: []string{"remote", "add", "--dry-run"},
+			err:  "flag provided but not defined: --dry-run",
+		},
+		{
+			args: []string{"remote", "add", "--verbsoe"},
+			err:  "flag provided but not defined: --verbsoe, did you mean --verbose?",
+		},
+	}
+
+	for _, tt := range tests {
+		root, _, add := newTestCommands()
+		verbose := SetFlag(root.Flags, "verbose", "v", false, "verbose flag", Persistent())
+		SetFlag(root.Flags, "dry-run", "", false, "dry-run flag")
+		add.Flags.SetCombinedShorthands(true)
+		SetFlag(add.Flags, "fetch", "f", false, "fetch flag")
+		t.Run("", func(t *testing.T) {
+			cmd, err := root.Parse(tt.args)
+			if tt.err != "" {
+				if err == nil || tt.err != err.Error() {
+					t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Name != tt.expected {
+				t.Fatalf("command did not match expected %q, got %q", tt.expected, cmd.Name)
+			}
+			if *verbose != tt.verbose {
+				t.Fatalf("flag value did not match expected %t, got %t", tt.verbose, *verbose)
+			}
+			if expected := []string{"origin"}; !reflect.DeepEqual(expected, cmd.Flags.Args()) {
+				t.Fatalf("arguments did not match expected %q, got %q", expected, cmd.Flags.Args())
+			}
+		})
+	}
+}
+
+func TestPersistentRequired(t *testing.T) {
+	path := writeConfig(t, "config.toml", `
+[remote.add]
+name = "origin"
+`)
+
+	root, _, add := newTestCommands()
+	SetFlag(root.Flags, "config", "c", "", "config flag", Persistent(), Required())
+	root.Flags.SetConfigFlag("config")
+	name := SetFlag(add.Flags, "name", "n", "", "name flag")
+
+	if _, err := root.Parse([]string{"remote", "add", "-c", path}); err != nil {
+		t.Fatal(err)
+	}
+	if *name != "origin" {
+		t.Fatalf("flag value did not match expected origin, got %s", *name)
+	}
+
+	root, _, _ = newTestCommands()
+	SetFlag(root.Flags, "config", "c", "", "config flag", Persistent(), Required())
+	expected := "missing required flag: --config"
+	if _, err := root.Parse([]string{"remote", "add"}); err == nil || expected != err.Error() {
+		t.Fatalf("error did not match expected %q, got %v", expected, err)
+	}
+}
+
+func TestCommandRequired(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: []string{"remote", "add"},
+			err:  "missing required flags: --region, --bucket",
+		},
+		{
+			args: []string{"--region", "eu", "remote", "add"},
+			err:  "missing required flag: --bucket",
+		},
+		{
+			args: []string{"remote", "add", "--bucket", "logs"},
+			err:  "missing required flag: --region",
+		},
+		{
+			args: []string{"--region", "eu", "remote", "add", "--bucket", "logs"},
+		},
+	}
+
+	for _, tt := range tests {
+		root, _, add := newTestCommands()
+		SetFlag(root.Flags, "region", "", "", "region flag", Required())
+		SetFlag(add.Flags, "bucket", "", "", "bucket flag", Required())
+		t.Run("", func(t *testing.T) {
+			_, err := root.Parse(tt.args)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var requiredErr *RequiredFlagsError
+			if !errors.As(err, &requiredErr) || tt.err != err.Error() {
+				t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestCommandLineUnknownCommand(t *testing.T) {
+	NewFlagSet("deploy", ContinueOnError)
+
+	expected := "unknown command dpeloy for " + CommandLine.Name() + ", did you mean deploy?"
+	if err := commandLine.unknownCommand("dpeloy"); err == nil || expected != err.Error() {
+		t.Fatalf("error did not match expected %q, got %v", expected, err)
+	}
+}
+
+func TestCommandLookup(t *testing.T) {
+	tests := []struct {
+		args     []string
+		prefixes bool
+		expected string
+		err      string
+	}{
+		{
+			args:     []string{"rm"},
+			expected: "remove",
+		},
+		{
+			args:     []string{"sta"},
+			expected: "tool",
+		},
+		{
+			args:     []string{"stat"},
+			prefixes: true,
+			expected: "status",
+		},
+		{
+			args:     []string{"remov"},
+			prefixes: true,
+			expected: "remove",
+		},
+		{
+			args:     []string{"rem", "add"},
+			expected: "add",
+		},
+		{
+			args:     []string{"remot", "a"},
+			prefixes: true,
+			expected: "add",
+		},
+		{
+			args:     []string{"r"},
+			prefixes: true,
+			expected: "remove",
+		},
+		{
+			args:     []string{"sta"},
+			prefixes: true,
+			err:      "command sta is ambiguous: status, stash",
+		},
+	}
+
+	for _, tt := range tests {
+		root, remote, _ := newTestCommands()
+		root.AddCommand(
+			NewCommand("status", "", nil),
+			NewCommand("stash", "", nil),
+			&Command{Name: "remove", Aliases: []string{"rm", "r"}, Flags: newFlagSet("remove", ContinueOnError)},
+		)
+		remote.Aliases = []string{"rem"}
+		root.SetPrefixMatching(tt.prefixes)
+		t.Run("", func(t *testing.T) {
+			for _, c := range root.Commands() {
+				c.Flags.SetOutput(io.Discard)
+			}
+
+			cmd, err := root.Parse(tt.args)
+			if tt.err != "" {
+				var ambiguousErr *AmbiguousCommandError
+				if !errors.As(err, &ambiguousErr) || tt.err != err.Error() {
+					t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cmd.Name != tt.expected {
+				t.Fatalf("command did not match expected %q, got %q", tt.expected, cmd.Name)
+			}
+		})
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{
+			args: []string{"remtoe", "add"},
+			err:  "unknown command remtoe for tool, did you mean remote?",
+		},
+		{
+			args: []string{"remote", "ad"},
+			err:  "unknown command ad for tool remote, did you mean add?",
+		},
+		{
+			args: []string{"remote", "dad"},
+			err:  "unknown command dad for tool remote, did you mean add?",
+		},
+		{
+			args: []string{"remote", "origin"},
+		},
+	}
+
+	for _, tt := range tests {
+		root, _, _ := newTestCommands()
+		t.Run("", func(t *testing.T) {
+			_, err := root.Parse(tt.args)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || tt.err != err.Error() {
+				t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,836 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SetConfigFile sets the config file read during Parse. The format of the file
+// is inferred from its extension, which must be one of .json, .toml, .yaml,
+// .yml or .ini.
+//
+// Keys in the file set the flags with the same name. Flags already given on
+// the command line or in the environment are not overridden. Keys in a section
+// named after a flag set, e.g. [sub] in TOML, set the flags of that flag set
+// when it is parsed as a subcommand. Keys in other tables set the flags
+// prefixed with the name of the table, e.g. host in [db] sets --db-host.
+//
+// The TOML and YAML parsers support the subset of the formats used for
+// configuration, with lists of scalars only:
+//   - TOML: tables and dotted keys, basic and literal strings including
+//     multi-line strings, numbers, booleans, arrays and inline tables. Arrays
+//     of tables are not supported.
+//   - YAML: block and flow mappings and sequences, plain and quoted scalars
+//     and literal (|) and folded (>) block scalars as mapping values. Anchors,
+//     tags and multiple documents are not supported.
+func (fs *FlagSet[T]) SetConfigFile(path string) {
+	fs.configFile = path
+}
+
+// SetConfigFlag sets the flag holding the path of the config file. When the
+// flag has a value it takes precedence over the path set with SetConfigFile.
+func (fs *FlagSet[T]) SetConfigFlag(name string) {
+	fs.configFlag = name
+}
+
+// SetIgnoreUnknownConfigKeys sets whether keys in the config file that do not
+// match any flag are ignored. By default unknown keys are an error.
+func (fs *FlagSet[T]) SetIgnoreUnknownConfigKeys(ignore bool) {
+	fs.ignoreUnknownKeys = ignore
+}
+
+// configPath returns the path of the config file of the flag set.
+func (fs *FlagSet[T]) configPath() string {
+	if fs.configFlag != "" {
+		if f := fs.Lookup(fs.configFlag); f != nil && f.Value.String() != "" {
+			return f.Value.String()
+		}
+	}
+	return fs.configFile
+}
+
+// parseConfig sets the flags that have not been set yet from the config file.
+// A flag set without a config file of its own reads its section from the
+// config file of the closest parent command with one, e.g. [remote.add] for
+// the command add of the command remote.
+func (fs *FlagSet[T]) parseConfig() error {
+	path, sections := fs.configPath(), []string(nil)
+	for p := fs.untyped(); path == "" && p.parent != nil; p = p.parent {
+		path, sections = p.parent.configPath(), append([]string{p.Name()}, sections...)
+	}
+	if path == "" {
+		return nil
+	}
+
+	values, err := readConfig(path)
+	if err != nil {
+		return err
+	}
+
+	for _, section := range sections {
+		values, _ = values[section].(map[string]any)
+	}
+	return fs.applyConfig(path, values)
+}
+
+// applyConfig sets the flags from the values read from the config file.
+func (fs *FlagSet[T]) applyConfig(path string, values map[string]any) error {
+	values = fs.flattenConfig(values)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+
+		if !fs.isConfigKey(key) {
+			if _, ok := value.(map[string]any); ok && fs.isConfigSection(key) {
+				continue
+			}
+			if fs.ignoreUnknownKeys {
+				continue
+			}
+			return fmt.Errorf("unknown key %q in config file %s", key, path)
+		}
+
+		if fs.Changed(key) {
+			continue
+		}
+
+		restore := fs.snapshot(key)
+		set := fs.configSetter(key, value)
+		for _, v := range configValues(value) {
+			if err := set(v); err != nil {
+				return fmt.Errorf("invalid value %q for flag --%s from config file %s: %v", v, key, path, err)
+			}
+		}
+		if err := fs.commit(key, SourceConfig, restore); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flattenConfig returns the values with the tables that are neither flags nor
+// sections of subcommands flattened to prefixed keys, e.g. host in the table
+// db to db-host. The keys match the flags of nested structs defined with Bind.
+func (fs *FlagSet[T]) flattenConfig(values map[string]any) map[string]any {
+	flat := make(map[string]any, len(values))
+	for key, value := range values {
+		table, ok := value.(map[string]any)
+		if !ok || fs.isConfigKey(key) || fs.isConfigSection(key) {
+			flat[key] = value
+			continue
+		}
+
+		prefixed := make(map[string]any, len(table))
+		for k, v := range table {
+			prefixed[key+"-"+k] = v
+		}
+		for k, v := range fs.flattenConfig(prefixed) {
+			flat[k] = v
+		}
+	}
+	return flat
+}
+
+// isConfigKey reports whether the key of the config file is the name of a
+// flag.
+func (fs *FlagSet[T]) isConfigKey(key string) bool {
+	info := fs.lookupInfo(key)
+	return info != nil && info.Longhand == key && fs.Lookup(key) != nil
+}
+
+// isConfigSection reports whether the key of the config file is the section
+// of a subcommand.
+func (fs *FlagSet[T]) isConfigSection(key string) bool {
+	return fs.command != nil && fs.command.child(key) != nil
+}
+
+// elementAdder is implemented by flag values holding multiple elements, such as
+// slice and map flags, which split the values given to Set on commas.
+type elementAdder interface {
+	addElement(value string) error
+}
+
+// configSetter returns the function setting the values of the flag from the
+// value read from the config file. The elements of lists and the pairs of
+// tables are added to slice and map flags as is, as they may contain commas.
+func (fs *FlagSet[T]) configSetter(key string, value any) func(string) error {
+	adder, ok := fs.Lookup(key).Value.(elementAdder)
+	switch v := value.(type) {
+	case []string:
+		// An empty list is set as an empty value, which clears the flag
+		if ok && len(v) > 0 {
+			return adder.addElement
+		}
+	case map[string]any:
+		if ok {
+			return adder.addElement
+		}
+	}
+	return func(s string) error {
+		return fs.FlagSet.Set(key, s)
+	}
+}
+
+// configValues returns the values passed to Set for a value read from the
+// config file. Each list element is set separately and tables are set as
+// key=value pairs.
+func configValues(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		if len(v) == 0 {
+			return []string{""}
+		}
+		return v
+	case map[string]any:
+		pairs := make([]string, 0, len(v))
+		for k, e := range v {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, e))
+		}
+		sort.Strings(pairs)
+		return pairs
+	case string:
+		return []string{v}
+	}
+	return nil
+}
+
+// readConfig reads the config file to a tree of values. Scalars are read as
+// strings, lists as []string and tables as map[string]any.
+func readConfig(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		values, err = parseJSON(data)
+	case ".toml":
+		values, err = parseTOML(data)
+	case ".yaml", ".yml":
+		values, err = parseYAML(data)
+	case ".ini":
+		values, err = parseINI(data)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %v", path, err)
+	}
+	return values, nil
+}
+
+func parseJSON(data []byte) (map[string]any, error) {
+	var values map[string]any
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&values); err != nil {
+		return nil, err
+	}
+
+	v, err := jsonValue(values)
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]any), nil
+}
+
+// jsonValue converts a decoded JSON value to a config value.
+func jsonValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []any:
+		list := make([]string, len(v))
+		for i, e := range v {
+			c, _ := jsonValue(e)
+			s, ok := c.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported list element %v", e)
+			}
+			list[i] = s
+		}
+		return list, nil
+	case map[string]any:
+		table := make(map[string]any, len(v))
+		for k, e := range v {
+			c, err := jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
+				table[k] = c
+			}
+		}
+		return table, nil
+	}
+	return nil, nil
+}
+
+// parseTOML parses the subset of TOML used for configuration: tables with
+// dotted names, dotted keys, basic and literal strings including multi-line
+// strings, numbers, booleans, arrays of scalars and inline tables.
+func parseTOML(data []byte) (map[string]any, error) {
+	values := make(map[string]any)
+	table := values
+
+	lines := strings.Split(string(data), "\n")
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(stripComment(lines[n], "#"))
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '[' {
+			if strings.HasPrefix(line, "[[") || !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unsupported table %s", n+1, line)
+			}
+			t, err := subTable(values, splitKey(line[1:len(line)-1]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+			table = t
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n+1)
+		}
+
+		var v any
+		var err error
+		if value = strings.TrimSpace(value); strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''") {
+			// Multi-line strings are read from the lines as is, as they may
+			// contain quotes and comment characters
+			_, raw, _ := strings.Cut(lines[n], "=")
+			v, n, err = parseTOMLMultiline(lines, n, strings.TrimSpace(raw))
+		} else {
+			// Arrays may span multiple lines
+			for start := n; !balanced(value); {
+				if n++; n == len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated value", start+1)
+				}
+				value += " " + stripComment(lines[n], "#")
+			}
+			v, err = parseFlowValue(value, tomlFlow)
+		}
+		if err == nil {
+			err = setKey(table, splitKey(key), v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n+1, err)
+		}
+	}
+
+	return values, nil
+}
+
+// parseTOMLMultiline parses the multi-line string starting with value on line
+// n. It returns the string and the index of the line the string ends on.
+func parseTOMLMultiline(lines []string, n int, value string) (string, int, error) {
+	delim, text := value[:3], value[3:]
+	start := n
+
+	var b strings.Builder
+	for {
+		if i := strings.Index(text, delim); i >= 0 {
+			b.WriteString(text[:i])
+			if rest := strings.TrimSpace(stripComment(text[i+3:], "#")); rest != "" {
+				return "", n, fmt.Errorf("unexpected %s after string", rest)
+			}
+			break
+		}
+		b.WriteString(strings.TrimSuffix(text, "\r") + "\n")
+		if n++; n == len(lines) {
+			return "", n, fmt.Errorf("line %d: unterminated string", start+1)
+		}
+		text = lines[n]
+	}
+
+	// A newline right after the opening delimiter is trimmed
+	s := strings.TrimPrefix(b.String(), "\n")
+	if delim == "'''" {
+		return s, n, nil
+	}
+	s, err := unescapeTOML(s)
+	return s, n, err
+}
+
+// unescapeTOML replaces the escapes of a multi-line basic string. A backslash
+// at the end of a line trims the newline and the whitespace following it.
+func unescapeTOML(s string) (string, error) {
+	var b strings.Builder
+	for len(s) > 0 {
+		if s[0] == '\\' {
+			if rest := strings.TrimLeft(s[1:], " \t\r"); strings.HasPrefix(rest, "\n") {
+				s = strings.TrimLeft(rest, " \t\r\n")
+				continue
+			}
+		}
+		if s[0] == '"' {
+			b.WriteByte('"')
+			s = s[1:]
+			continue
+		}
+
+		r, _, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in string")
+		}
+		b.WriteRune(r)
+		s = tail
+	}
+	return b.String(), nil
+}
+
+// splitKey splits a dotted TOML key such as db.host or "a.b".c to its
+// unquoted parts.
+func splitKey(key string) []string {
+	var keys []string
+	start := 0
+	unquoted(key, func(i int) bool {
+		if key[i] == '.' {
+			keys = append(keys, parseScalar(strings.TrimSpace(key[start:i])))
+			start = i + 1
+		}
+		return true
+	})
+	return append(keys, parseScalar(strings.TrimSpace(key[start:])))
+}
+
+// subTable returns the table at the dotted key in table, creating the missing
+// tables.
+func subTable(table map[string]any, keys []string) (map[string]any, error) {
+	for _, key := range keys {
+		next, ok := table[key].(map[string]any)
+		if !ok {
+			if _, exists := table[key]; exists {
+				return nil, fmt.Errorf("key %s is not a table", key)
+			}
+			next = make(map[string]any)
+			table[key] = next
+		}
+		table = next
+	}
+	return table, nil
+}
+
+// setKey sets the value of the dotted key in table.
+func setKey(table map[string]any, keys []string, value any) error {
+	table, err := subTable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	table[keys[len(keys)-1]] = value
+	return nil
+}
+
+// yamlLine is a non-empty line of a YAML document.
+type yamlLine struct {
+	n      int
+	indent int
+	text   string
+	// block holds the value of a key with a block scalar, e.g. key: |
+	block *string
+}
+
+// parseYAML parses the subset of YAML used for configuration: nested
+// mappings, block and flow sequences of scalars, flow mappings and literal and
+// folded block scalars of mappings.
+func parseYAML(data []byte) (map[string]any, error) {
+	var lines []yamlLine
+	raw := strings.Split(string(data), "\n")
+	for n := 0; n < len(raw); n++ {
+		line := raw[n]
+		// Comments start with # at the start of the line or after a space
+		text := strings.TrimSpace(stripComment(line, " #"))
+		if text == "" || text == "---" || text[0] == '#' {
+			continue
+		}
+		indent := yamlIndent(line)
+		if line[indent] == '\t' {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", n+1)
+		}
+
+		l := yamlLine{n: n + 1, indent: indent, text: text}
+		if key, header, ok := cutBlockScalar(text); ok {
+			// The block scalar holds the following lines indented more
+			// than the key, as well as blank lines
+			var content []string
+			for n+1 < len(raw) && (strings.TrimSpace(raw[n+1]) == "" || yamlIndent(raw[n+1]) > indent) {
+				content = append(content, strings.TrimSuffix(raw[n+1], "\r"))
+				n++
+			}
+			block := parseBlockScalar(content, header)
+			l.text, l.block = key+":", &block
+		}
+		lines = append(lines, l)
+	}
+
+	if len(lines) == 0 {
+		return make(map[string]any), nil
+	}
+
+	v, i, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if i < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[i].n)
+	}
+
+	values, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("line %d: expected mapping", lines[0].n)
+	}
+	return values, nil
+}
+
+// parseYAMLBlock parses the block starting from line i with the given
+// indentation. It returns the parsed value and the index of the next line.
+func parseYAMLBlock(lines []yamlLine, i int, indent int) (any, int, error) {
+	if isYAMLListItem(lines[i].text) {
+		list := []string{}
+		for ; i < len(lines) && lines[i].indent == indent && isYAMLListItem(lines[i].text); i++ {
+			list = append(list, parseYAMLScalar(strings.TrimSpace(lines[i].text[1:])))
+		}
+		return list, i, nil
+	}
+
+	values := make(map[string]any)
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		i++
+
+		key, value, ok := strings.Cut(line.text, ": ")
+		if !ok {
+			if !strings.HasSuffix(line.text, ":") {
+				return nil, i, fmt.Errorf("line %d: expected key: value", line.n)
+			}
+			key = line.text[:len(line.text)-1]
+		}
+		key = parseYAMLScalar(strings.TrimSpace(key))
+
+		if line.block != nil {
+			values[key] = *line.block
+			continue
+		}
+
+		if value = strings.TrimSpace(value); value != "" {
+			v, err := parseFlowValue(value, yamlFlow)
+			if err != nil {
+				return nil, i, fmt.Errorf("line %d: %v", line.n, err)
+			}
+			values[key] = v
+			continue
+		}
+
+		// Block sequences may have the same indentation as the key
+		if i < len(lines) && (lines[i].indent > indent || lines[i].indent == indent && isYAMLListItem(lines[i].text)) {
+			v, next, err := parseYAMLBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, next, err
+			}
+			values[key], i = v, next
+			continue
+		}
+		values[key] = ""
+	}
+
+	return values, i, nil
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func yamlIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// cutBlockScalar returns the key and the header of a block scalar such as
+// key: | or key: >-, and reports whether the line starts one.
+func cutBlockScalar(text string) (string, string, bool) {
+	key, value, ok := strings.Cut(text, ": ")
+	switch value = strings.TrimSpace(value); value {
+	case "|", "|-", "|+", ">", ">-", ">+":
+		return key, value, ok
+	}
+	return "", "", false
+}
+
+// parseBlockScalar returns the value of a block scalar with the given header
+// from its lines. Literal scalars (|) keep the line breaks and folded scalars
+// (>) join lines with a space. The final line break is kept by default,
+// removed with - and kept with the trailing blank lines with +.
+func parseBlockScalar(lines []string, header string) string {
+	trailing := 0
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	indent := yamlIndent(lines[0])
+	for i, line := range lines {
+		if len(line) > indent {
+			lines[i] = line[indent:]
+		} else {
+			lines[i] = ""
+		}
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		switch {
+		case i == 0:
+		case header[0] == '|' || line == "":
+			b.WriteString("\n")
+		case lines[i-1] == "":
+			// The line break was written for the blank line
+		default:
+			b.WriteString(" ")
+		}
+		b.WriteString(line)
+	}
+
+	switch {
+	case strings.HasSuffix(header, "-"):
+	case strings.HasSuffix(header, "+"):
+		b.WriteString(strings.Repeat("\n", trailing+1))
+	default:
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// parseINI parses INI files with sections and key = value or key: value
+// pairs. Repeated keys are read as a list.
+func parseINI(data []byte) (map[string]any, error) {
+	values := make(map[string]any)
+	table := values
+
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section %s", n+1, line)
+			}
+			table = make(map[string]any)
+			values[strings.TrimSpace(line[1:len(line)-1])] = table
+			continue
+		}
+
+		i := strings.IndexAny(line, "=:")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", n+1)
+		}
+		key, value := strings.TrimSpace(line[:i]), parseScalar(strings.TrimSpace(line[i+1:]))
+
+		switch v := table[key].(type) {
+		case string:
+			table[key] = []string{v, value}
+		case []string:
+			table[key] = append(v, value)
+		default:
+			table[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+// flowSyntax holds the differences of TOML and YAML in flow values.
+type flowSyntax struct {
+	// sep separates the keys and values of tables
+	sep string
+	// scalar returns a scalar without quotes
+	scalar func(string) string
+	// dotted is set when the keys of tables are dotted, e.g. db.host
+	dotted bool
+}
+
+var (
+	tomlFlow = flowSyntax{sep: "=", scalar: parseScalar, dotted: true}
+	yamlFlow = flowSyntax{sep: ":", scalar: parseYAMLScalar}
+)
+
+// parseFlowValue parses a scalar, a list in brackets or a table in braces.
+func parseFlowValue(s string, syntax flowSyntax) (any, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+
+	switch s[0] {
+	case '[':
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated list %s", s)
+		}
+		list := []string{}
+		for _, e := range splitFlow(s[1 : len(s)-1]) {
+			if e = strings.TrimSpace(e); e == "" {
+				continue
+			}
+			if e[0] == '[' || e[0] == '{' {
+				return nil, fmt.Errorf("unsupported list element %s", e)
+			}
+			list = append(list, syntax.scalar(e))
+		}
+		return list, nil
+	case '{':
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("unterminated table %s", s)
+		}
+		table := make(map[string]any)
+		for _, e := range splitFlow(s[1 : len(s)-1]) {
+			if strings.TrimSpace(e) == "" {
+				continue
+			}
+			k, v, ok := strings.Cut(e, syntax.sep)
+			if !ok {
+				return nil, fmt.Errorf("expected key%svalue, got %s", syntax.sep, e)
+			}
+			value, err := parseFlowValue(v, syntax)
+			if err != nil {
+				return nil, err
+			}
+			if !syntax.dotted {
+				table[syntax.scalar(strings.TrimSpace(k))] = value
+			} else if err := setKey(table, splitKey(k), value); err != nil {
+				return nil, err
+			}
+		}
+		return table, nil
+	}
+
+	return syntax.scalar(s), nil
+}
+
+// parseScalar returns the scalar without quotes. Escapes are replaced in
+// double quoted scalars, while single quoted scalars are taken literally.
+func parseScalar(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	switch {
+	case s[0] == '"' && s[len(s)-1] == '"':
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	case s[0] == '\'' && s[len(s)-1] == '\'':
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// parseYAMLScalar returns the YAML scalar without quotes. Single quotes are
+// escaped in single quoted scalars by doubling them.
+func parseYAMLScalar(s string) string {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return parseScalar(s)
+}
+
+// splitFlow splits s at the commas outside of quotes, brackets and braces.
+func splitFlow(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+
+	unquoted(s, func(i int) bool {
+		switch s[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+		return true
+	})
+
+	return append(parts, s[start:])
+}
+
+// balanced reports whether the brackets and braces outside of quotes are
+// balanced in s.
+func balanced(s string) bool {
+	depth := 0
+	unquoted(s, func(i int) bool {
+		switch s[i] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		}
+		return true
+	})
+	return depth <= 0
+}
+
+// stripComment removes the comment starting with prefix outside of quotes.
+func stripComment(line string, prefix string) string {
+	end := len(line)
+	unquoted(line, func(i int) bool {
+		if strings.HasPrefix(line[i:], prefix) {
+			end = i
+			return false
+		}
+		return true
+	})
+	return line[:end]
+}
+
+// unquoted calls fn with the index of each byte of s outside of single or
+// double quotes, until fn returns false.
+func unquoted(s string, fn func(i int) bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		default:
+			if !fn(i) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package miniflag
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigFormats(t *testing.T) {
+	type result struct {
+		name   string
+		port   int
+		debug  bool
+		tags   []string
+		labels map[string]string
+	}
+
+	expected := result{
+		name:   "server # 1",
+		port:   8080,
+		debug:  true,
+		tags:   []string{"a", "b"},
+		labels: map[string]string{"env": "prod", "team": "core"},
+	}
+
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{
+			file: "config.json",
+			content: `{
+	"name": "server # 1",
+	"port": 8080,
+	"debug": true,
+	"tags": ["a", "b"],
+	"labels": {"env": "prod", "team": "core"}
+}`,
+		},
+		{
+			file: "config.toml",
+			content: `# server config
+name = "server # 1"
+port = 8080 # inline comment
+debug = true
+tags = [
+	"a",
+	'b',
+]
+labels = { env = "prod", team = "core" }
+`,
+		},
+		{
+			file: "config.yaml",
+			content: `---
+# server config
+name: "server # 1"
+port: 8080 # inline comment
+debug: true
+tags:
+  - a
+  - 'b'
+labels:
+  env: prod
+  team: core
+`,
+		},
+		{
+			file: "config.yml",
+			content: `name: 'server # 1'
+port: 8080
+debug: true
+tags: [a, b]
+labels: {env: prod, team: core}
+`,
+		},
+		{
+			file: "config.ini",
+			content: `; server config
+name = server # 1
+port: 8080
+debug = true
+tags = a
+tags = b
+labels = env=prod,team=core
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run(tt.file, func(t *testing.T) {
+			fs.SetConfigFile(writeConfig(t, tt.file, tt.content))
+			name := SetFlag(fs, "name", "n", "", "name flag")
+			port := SetFlag(fs, "port", "p", 0, "port flag")
+			debug := SetFlag(fs, "debug", "d", false, "debug flag")
+			tags := SetFlag(fs, "tags", "t", []string{"default"}, "tags flag")
+			labels := SetFlag(fs, "labels", "l", map[string]string{}, "labels flag")
+
+			if err := fs.Parse(nil); err != nil {
+				t.Fatal(err)
+			}
+
+			actual := result{name: *name, port: *port, debug: *debug, tags: *tags, labels: *labels}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("flag values did not match expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestConfigSyntax(t *testing.T) {
+	tests := []struct {
+		parse    func([]byte) (map[string]any, error)
+		content  string
+		expected map[string]any
+	}{
+		{
+			parse: parseTOML,
+			content: `
+db.host = "h"
+"a.b".c = 'x'
+inline = { db.port = 1 }
+
+[server]
+tls.cert = "c"
+`,
+			expected: map[string]any{
+				"db":     map[string]any{"host": "h"},
+				"a.b":    map[string]any{"c": "x"},
+				"inline": map[string]any{"db": map[string]any{"port": "1"}},
+				"server": map[string]any{"tls": map[string]any{"cert": "c"}},
+			},
+		},
+		{
+			parse: parseTOML,
+			content: `
+basic = """
+first "line" # not a comment
+second\tline \
+    continued"""
+literal = '''
+C:\path\'''
+quoted = '''it''s'''
+`,
+			expected: map[string]any{
+				"basic":   "first \"line\" # not a comment\nsecond\tline continued",
+				"literal": "C:\\path\\",
+				"quoted":  "it''s",
+			},
+		},
+		{
+			parse: parseYAML,
+			content: `
+literal: |
+  first
+   indented
+
+  last
+folded: >-
+  first
+  second
+
+  third
+keep: |+
+  text
+
+quoted: 'it''s'
+`,
+			expected: map[string]any{
+				"literal": "first\n indented\n\nlast\n",
+				"folded":  "first second\nthird",
+				"keep":    "text\n\n",
+				"quoted":  "it's",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			actual, err := tt.parse([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("values did not match expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, "config.toml", `
+name = "config"
+port = 3000
+level = "debug"
+`)
+	t.Setenv("PORT", "4000")
+
+	fs := NewFlagSet("", ContinueOnError)
+	fs.SetConfigFile(path)
+	name := SetFlag(fs, "name", "n", "default", "name flag")
+	port := SetFlag(fs, "port", "p", 0, "port flag", Env("PORT"))
+	level := SetFlag(fs, "level", "l", "info", "level flag")
+
+	if err := fs.Parse([]string{"-n", "cli"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if *name != "cli" || *port != 4000 || *level != "debug" {
+		t.Fatalf("flag values did not match expected cli 4000 debug, got %s %d %s", *name, *port, *level)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+		ignore  bool
+		err     string
+	}{
+		{
+			file:    "config.toml",
+			content: `unknown = 1`,
+			err:     `unknown key "unknown" in config file`,
+		},
+		{
+			file:    "config.toml",
+			content: `unknown = 1`,
+			ignore:  true,
+		},
+		{
+			file:    "config.json",
+			content: `{"port": "http"}`,
+			err:     `invalid value "http" for flag --port from config file`,
+		},
+		{
+			file:    "config.toml",
+			content: "port = [1,\n2",
+			err:     "line 1: unterminated value",
+		},
+		{
+			file:    "config.yaml",
+			content: "port: 1\n  name: a",
+			err:     "line 2: unexpected indentation",
+		},
+		{
+			file:    "config.ini",
+			content: "[section",
+			err:     "line 1: unterminated section",
+		},
+		{
+			file:    "config.xml",
+			content: "<port>1</port>",
+			err:     "unsupported config file format",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run(tt.file, func(t *testing.T) {
+			fs.SetConfigFile(writeConfig(t, tt.file, tt.content))
+			fs.SetIgnoreUnknownConfigKeys(tt.ignore)
+			SetFlag(fs, "port", "p", 0, "port flag")
+
+			err := fs.Parse(nil)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "port: 3000\n")
+
+	fs := NewFlagSet("", ContinueOnError)
+	fs.SetConfigFlag("config")
+	SetFlag(fs, "config", "c", "", "config file flag")
+	port := SetFlag(fs, "port", "p", 0, "port flag")
+
+	if err := fs.Parse([]string{"--config", path}); err != nil {
+		t.Fatal(err)
+	}
+
+	if *port != 3000 {
+		t.Fatalf("flag value did not match expected 3000, got %d", *port)
+	}
+}
+
+func TestConfigCommas(t *testing.T) {
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{
+			file: "config.toml",
+			content: `
+tags = ["a", "b, c"]
+labels = { env = "dev, test" }
+`,
+		},
+		{
+			file: "config.yaml",
+			content: `
+tags:
+  - a
+  - "b, c"
+labels:
+  env: "dev, test"
+`,
+		},
+		{
+			file:    "config.json",
+			content: `{"tags": ["a", "b, c"], "labels": {"env": "dev, test"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run(tt.file, func(t *testing.T) {
+			fs.SetConfigFile(writeConfig(t, tt.file, tt.content))
+			tags := SetFlag(fs, "tags", "t", []string{"default"}, "tags flag")
+			labels := SetFlag(fs, "labels", "l", map[string]string{}, "labels flag")
+
+			if err := fs.Parse(nil); err != nil {
+				t.Fatal(err)
+			}
+
+			if expected := []string{"a", "b, c"}; !reflect.DeepEqual(expected, *tags) {
+				t.Fatalf("flag value did not match expected %q, got %q", expected, *tags)
+			}
+			if expected := map[string]string{"env": "dev, test"}; !reflect.DeepEqual(expected, *labels) {
+				t.Fatalf("flag value did not match expected %q, got %q", expected, *labels)
+			}
+		})
+	}
+}
+
+func TestConfigSection(t *testing.T) {
+	path := writeConfig(t, "config.toml", `
+verbose = true
+
+[serve]
+port = 3000
+
+[remote.add]
+name = "origin"
+`)
+
+	root := NewCommand("root", "", nil)
+	root.Flags.SetConfigFile(path)
+	verbose := SetFlag(root.Flags, "verbose", "v", false, "verbose flag")
+
+	serve := NewCommand("serve", "", nil)
+	serve.Flags.SetOutput(io.Discard)
+	port := SetFlag(serve.Flags, "port", "p", 0, "port flag")
+
+	remote := NewCommand("remote", "", nil)
+	add := NewCommand("add", "", nil)
+	name := SetFlag(add.Flags, "name", "n", "", "name flag")
+	remote.AddCommand(add)
+	root.AddCommand(serve, remote)
+
+	if _, err := root.Parse([]string{"serve", "arg"}); err != nil {
+		t.Fatal(err)
+	}
+	if *port != 3000 || !*verbose {
+		t.Fatalf("flag values did not match expected 3000 true, got %d %t", *port, *verbose)
+	}
+
+	if _, err := root.Parse([]string{"serve", "-v"}); err == nil {
+		t.Fatal("expected parse error for root flag in subcommand")
+	}
+
+	if _, err := root.Parse([]string{"remote", "add"}); err != nil {
+		t.Fatal(err)
+	}
+	if *name != "origin" {
+		t.Fatalf("flag value did not match expected origin, got %s", *name)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import "strconv"
+
+// Counter is a flag value that counts the occurrences of the flag. Each
+// occurrence without a value increments the count, so -v -v and -vv both count
+// two. A value given with "=", e.g. --verbose=3, sets the count directly.
+//
+//	var verbosity = miniflag.Flag("verbose", "v", miniflag.Counter(0), "increase verbosity")
+type Counter int
+
+func (c *Counter) Set(s string) error {
+	// Flags without a value are set to "true" when parsed
+	if s == "true" {
+		*c++
+		return nil
+	}
+
+	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	if err != nil {
+		return numError(err)
+	}
+	*c = Counter(v)
+	return nil
+}
+
+func (c *Counter) Get() any {
+	return int(*c)
+}
+
+func (c *Counter) String() string {
+	return strconv.Itoa(int(*c))
+}
+
+// IsBoolFlag reports that the counter does not require a value.
+func (c *Counter) IsBoolFlag() bool {
+	return true
+}
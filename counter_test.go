@@ -0,0 +1,69 @@
+package miniflag
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected Counter
+	}{
+		{
+			expected: 0,
+		},
+		{
+			args:     []string{"-v"},
+			expected: 1,
+		},
+		{
+			args:     []string{"-v", "--verbose", "-v"},
+			expected: 3,
+		},
+		{
+			args:     []string{"-vvv"},
+			expected: 3,
+		},
+		{
+			args:     []string{"-vv", "-v"},
+			expected: 3,
+		},
+		{
+			args:     []string{"--verbose=3"},
+			expected: 3,
+		},
+		{
+			args:     []string{"-v", "--verbose=0"},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "verbose", "v", Counter(0), "Test counter flag")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %d, got %d", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestCounterUsage(t *testing.T) {
+	var b bytes.Buffer
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&b)
+	SetFlag(fs, "verbose", "v", Counter(0), "Increase `level` of verbosity")
+	fs.Usage()
+
+	expected := "usage: test [-v --verbose]\n    -v --verbose    Increase `level` of verbosity\n"
+	if actual := b.String(); expected != actual {
+		t.Fatalf("Help string did not match expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// choicesValue is implemented by flag values restricted to a fixed set of
+// values.
+type choicesValue interface {
+	choices() []string
+}
+
+// EnumValue is a flag value restricted to a fixed set of strings. Values not
+// in the set are rejected when parsing, suggesting the closest allowed value.
+// Create an EnumValue with Enum.
+type EnumValue[T ~string] struct {
+	value   T
+	allowed []T
+}
+
+// Enum returns an EnumValue allowing the given values, which is used as the
+// default value of a flag. The first value is the default value.
+//
+//	var format = miniflag.Flag("format", "f", miniflag.Enum("text", "json"), "output format")
+//	// Inferred as *miniflag.EnumValue[string]
+//
+// Enum panics if no values are given.
+func Enum[T ~string](values ...T) EnumValue[T] {
+	if len(values) == 0 {
+		panic("enum requires at least one value")
+	}
+	return EnumValue[T]{value: values[0], allowed: values}
+}
+
+// Value returns the selected value.
+func (e *EnumValue[T]) Value() T {
+	return e.value
+}
+
+// Choices returns the allowed values.
+func (e *EnumValue[T]) Choices() []T {
+	return e.allowed
+}
+
+func (e *EnumValue[T]) Set(s string) error {
+	for _, v := range e.allowed {
+		if string(v) == s {
+			e.value = v
+			return nil
+		}
+	}
+
+	choices := e.choices()
+	err := fmt.Sprintf("%s is not one of %s", s, strings.Join(choices, ", "))
+	if suggestion := suggest(s, choices); suggestion != "" {
+		err += fmt.Sprintf(", did you mean %s?", suggestion)
+	}
+	return errors.New(err)
+}
+
+func (e *EnumValue[T]) Get() any {
+	return e.value
+}
+
+func (e *EnumValue[T]) String() string {
+	return string(e.value)
+}
+
+func (e *EnumValue[T]) choices() []string {
+	choices := make([]string, len(e.allowed))
+	for i, v := range e.allowed {
+		choices[i] = string(v)
+	}
+	return choices
+}
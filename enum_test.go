@@ -0,0 +1,89 @@
+package miniflag
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type outputFormat string
+
+const (
+	textFormat outputFormat = "text"
+	jsonFormat outputFormat = "json"
+	yamlFormat outputFormat = "yaml"
+)
+
+func TestEnum(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected outputFormat
+		err      string
+	}{
+		{
+			expected: textFormat,
+		},
+		{
+			args:     []string{"--format", "yaml"},
+			expected: yamlFormat,
+		},
+		{
+			args: []string{"-f", "jsn"},
+			err:  `invalid value "jsn" for flag -f: jsn is not one of text, json, yaml, did you mean json?`,
+		},
+		{
+			args: []string{"--format", "xml"},
+			err:  `invalid value "xml" for flag --format: xml is not one of text, json, yaml`,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "format", "f", Enum(textFormat, jsonFormat, yamlFormat), "format flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err != "" {
+				if err == nil || tt.err != err.Error() {
+					t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != actual.Value() {
+				t.Fatalf("flag value did not match expected %q, got %q", tt.expected, actual.Value())
+			}
+		})
+	}
+}
+
+func TestEnumUsage(t *testing.T) {
+	var b bytes.Buffer
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&b)
+	SetFlag(fs, "format", "f", Enum("text", "json"), "Usage for `format`")
+	fs.Usage()
+
+	expected := "usage: test [-f --format=text|json]\n    -f --format     Usage for `format`\n"
+	if actual := b.String(); expected != actual {
+		t.Fatalf("Help string did not match expected %q, got %q", expected, actual)
+	}
+
+	if expected := []string{"text", "json"}; !reflect.DeepEqual(expected, fs.Choices("format")) {
+		t.Fatalf("choices did not match expected %q, got %q", expected, fs.Choices("format"))
+	}
+}
+
+func TestEnumEmpty(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	Enum[string]()
+}
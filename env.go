@@ -0,0 +1,62 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// SetEnvPrefix binds every flag in the flag set to an environment variable
+// derived from the prefix and the flag name. For example with the prefix
+// MYAPP the flag log-level is read from MYAPP_LOG_LEVEL. Flags with an
+// explicit variable set with the Env option use that variable instead.
+func (fs *FlagSet[T]) SetEnvPrefix(prefix string) {
+	fs.envPrefix = prefix
+}
+
+// envName returns the environment variable of the flag, or an empty string if
+// the flag is not bound to one.
+func (fs *FlagSet[T]) envName(f flagInfo) string {
+	if f.Env != "" || fs.envPrefix == "" || f.Longhand == "" {
+		return f.Env
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, f.Longhand)
+
+	return fs.envPrefix + "_" + name
+}
+
+// parseEnv sets the flags not given in the arguments from their environment
+// variables. Empty variables are ignored.
+func (fs *FlagSet[T]) parseEnv() error {
+	for _, info := range fs.flags {
+		env := fs.envName(info)
+		if env == "" || fs.Changed(info.Longhand) {
+			continue
+		}
+
+		value := os.Getenv(env)
+		if value == "" || fs.Lookup(info.Longhand) == nil {
+			continue
+		}
+
+		restore := fs.snapshot(info.Longhand)
+		if err := fs.FlagSet.Set(info.Longhand, value); err != nil {
+			return fmt.Errorf("invalid value %q for flag --%s from environment variable %s: %v", value, info.Longhand, env, err)
+		}
+		if err := fs.commit(info.Longhand, SourceEnv, restore); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
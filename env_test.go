@@ -0,0 +1,119 @@
+package miniflag
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	tests := []struct {
+		args     []string
+		env      map[string]string
+		prefix   string
+		expected string
+		err      string
+	}{
+		{
+			expected: "info",
+		},
+		{
+			env:      map[string]string{"LOG_LEVEL": "debug"},
+			expected: "debug",
+		},
+		{
+			args:     []string{"--log-level", "warn"},
+			env:      map[string]string{"LOG_LEVEL": "debug"},
+			expected: "warn",
+		},
+		{
+			args:     []string{"-l", "warn"},
+			env:      map[string]string{"LOG_LEVEL": "debug"},
+			expected: "warn",
+		},
+		{
+			env:      map[string]string{"LOG_LEVEL": ""},
+			expected: "info",
+		},
+		{
+			env:      map[string]string{"MYAPP_LOG_LEVEL": "debug"},
+			prefix:   "MYAPP",
+			expected: "debug",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetEnvPrefix(tt.prefix)
+		t.Run("", func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var opts []Option
+			if tt.prefix == "" {
+				opts = append(opts, Env("LOG_LEVEL"))
+			}
+			actual := SetFlag(fs, "log-level", "l", "info", "log level flag", opts...)
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %q, got %q", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestEnvPrefix(t *testing.T) {
+	t.Setenv("MYAPP_PORT", "8080")
+	t.Setenv("MYAPP_DB_HOST", "db")
+	t.Setenv("ADDR", "localhost")
+
+	fs := NewFlagSet("", ContinueOnError)
+	fs.SetEnvPrefix("MYAPP")
+	port := SetFlag(fs, "port", "p", 0, "port flag")
+	host := SetFlag(fs, "db.host", "", "", "db host flag")
+	addr := SetFlag(fs, "addr", "", "", "addr flag", Env("ADDR"))
+
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if *port != 8080 || *host != "db" || *addr != "localhost" {
+		t.Fatalf("flag values did not match expected 8080 db localhost, got %d %s %s", *port, *host, *addr)
+	}
+}
+
+func TestEnvError(t *testing.T) {
+	t.Setenv("PORT", "http")
+
+	fs := NewFlagSet("", ContinueOnError)
+	fs.SetOutput(io.Discard)
+	SetFlag(fs, "port", "p", 0, "port flag", Env("PORT"))
+
+	expected := `invalid value "http" for flag --port from environment variable PORT: parse error`
+	if err := fs.Parse(nil); err == nil || expected != err.Error() {
+		t.Fatalf("error did not match expected %q, got %v", expected, err)
+	}
+}
+
+func TestEnvUsage(t *testing.T) {
+	var b bytes.Buffer
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&b)
+	fs.SetEnvPrefix("TEST")
+	SetFlag(fs, "port", "p", 0, "Usage for port")
+	SetFlag(fs, "addr", "a", "", "Usage for addr", Env("ADDR"))
+	fs.Usage()
+
+	expected := `usage: test [-p --port] [-a --addr]
+    -p --port       Usage for port [env: TEST_PORT]
+    -a --addr       Usage for addr [env: ADDR]
+`
+	if actual := b.String(); expected != actual {
+		t.Fatalf("Help string did not match expected %q, got %q", expected, actual)
+	}
+}
@@ -51,8 +51,10 @@
 package miniflag
 
 import (
+	"encoding"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strings"
@@ -75,52 +77,101 @@ const (
 var (
 	// CommandLine is the default set of command-line flags, parsed from
 	// os.Args.
-	CommandLine = NewFlagSet(os.Args[0], ExitOnError)
-	// Setup capacity for optimized performance
-	flagSets = make(map[string]FlagSet[any], 8)
-	// Increase performance by pre-allocating slice capacity
-	// flagInfoSlice is used in new FlagSet creation
-	flagInfoSlice = make([]flagInfo, 0, 32)
+	CommandLine = newFlagSet(os.Args[0], ExitOnError)
 )
 
+// Increase performance by pre-allocating slice capacity
+// flagInfoCap is used in new FlagSet creation
+const flagInfoCap = 32
+
 // A FlagSet represents a set of defined flags. The zero value of a FlagSet has
 // no name and has ContinueOnError error handling.
 //
 // Flag names must be unique within a FlagSet. An attempt to define a flag
 // whose name is already in use will cause a panic.
+// The type parameter T is the type of the value of a typed flag set, see
+// NewTypedFlagSet. The fields must not depend on T, which allows converting
+// flag sets of any type to FlagSet[any].
 // NOTE: Direct reference to standard lib.
 type FlagSet[T any] struct {
 	*flag.FlagSet
 	flags []flagInfo
-	// TODO: move flagSets into FlagSet
-	// Sub flagsets are commands for parent flagset/command
+	// types holds the parsers registered with RegisterFlagSetType
+	types map[reflect.Type]any
+	// args holds the non-flag arguments after parsing
+	args   []string
+	parsed bool
+	// combinedShorthands enables parsing of combined shorthands, e.g. -abc
+	combinedShorthands bool
+	// strictDashes requires two dashes for names and one for shorthands
+	strictDashes bool
+	// interspersed allows flags after non-flag arguments
+	interspersed bool
+	// envPrefix is used to derive environment variables for flags
+	envPrefix string
+	// configFile and configFlag define the config file read during parsing
+	configFile        string
+	configFlag        string
+	ignoreUnknownKeys bool
+	// sources records where the value of each set flag came from
+	sources map[string]Source
+	// groups holds the flag groups checked after parsing
+	groups []flagGroup
+	// validators and choices hold the validation rules of the flags, and
+	// validated the values checked by the validators
+	validators map[string][]func() error
+	validated  map[string]reflect.Value
+	choices    map[string][]string
+	// value holds the *T bound to a typed flag set
+	value any
+	// parent is the flag set of the parent command
+	parent *FlagSet[any]
+	// command is the command the flag set belongs to
+	command *Command
 }
 
 func (fs *FlagSet[T]) defaultUsage() {
 	usage(fs)
 }
 
+// untyped returns the flag set as FlagSet[any], which is used to define
+// flags.
+func (fs *FlagSet[T]) untyped() *FlagSet[any] {
+	return (*FlagSet[any])(fs)
+}
+
 // SetFlag defines a new flag to a given FlagSet.
-func SetFlag[T any](fs *FlagSet[any], name string, shorthand string, value T, usage string) *T {
-	return defineFlag(fs, name, shorthand, value, usage)
+func SetFlag[T, C any](fs *FlagSet[C], name string, shorthand string, value T, usage string, opts ...Option) *T {
+	return defineFlag(fs.untyped(), name, shorthand, value, usage, opts...)
 }
 
 // Flag defines a new flag for CommandLine with the given name, shorthand,
 // usage and value. Value type is inferred from the given value. Shorthand for
 // the flag is only created if passed shorthand parameter is not an empty
-// string.
-func Flag[T any](name string, shorthand string, value T, usage string) *T {
-	return defineFlag(CommandLine, name, shorthand, value, usage)
+// string. Options can be given to further configure the flag.
+func Flag[T any](name string, shorthand string, value T, usage string, opts ...Option) *T {
+	return defineFlag(CommandLine, name, shorthand, value, usage, opts...)
 }
 
 // NewFlagSet returns a new, empty flag set with the specified name and error
-// handling property.
+// handling property. The flag set is a subcommand of CommandLine, which Parse
+// dispatches to when the name of the flag set is the first argument. Use
+// NewCommand for trees of commands.
 func NewFlagSet(name string, errorHandling ErrorHandling) *FlagSet[any] {
-	fs := FlagSet[any]{&flag.FlagSet{}, flagInfoSlice}
+	fs := newFlagSet(name, errorHandling)
+	commandLine.setCommand(&Command{Name: name, Flags: fs})
+	return fs
+}
+
+func newFlagSet(name string, errorHandling ErrorHandling) *FlagSet[any] {
+	fs := &FlagSet[any]{
+		FlagSet:      &flag.FlagSet{},
+		flags:        make([]flagInfo, 0, flagInfoCap),
+		interspersed: true,
+	}
 	fs.Usage = fs.defaultUsage
 	fs.Init(name, errorHandling)
-	flagSets[name] = fs
-	return &fs
+	return fs
 }
 
 // Args returns non-flag arguments.
@@ -138,127 +189,316 @@ type flagInfo struct {
 	Shorthand  string
 	UsageValue string
 	Usage      string
+	// Negatable bool flags can be set to false with --no-<name>
+	Negatable bool
+	// OptionalValue flags are set to NoValue when given without a value
+	OptionalValue bool
+	NoValue       string
+	// Env is the environment variable the flag is read from
+	Env string
+	// Required flags must be set from some source when parsing
+	Required bool
+	// Persistent flags are accepted in the arguments of subcommands
+	Persistent bool
 }
 
 func parse(fs *FlagSet[any], args []string) error {
-	l := len(args)
-	if l > 1 {
-		if f, ok := flagSets[args[0]]; ok {
-			return f.Parse(args[1:])
-		}
+	if fs.command != nil {
+		_, err := fs.command.Parse(args)
+		return err
 	}
 	return fs.Parse(args)
 }
 
 func args(fs *FlagSet[any]) []string {
-	args := fs.Args()
-
-	pArgs := []string{}
-	for i, arg := range args {
-		if arg == "" {
-			pArgs = append(pArgs, arg)
-			continue
-		}
-
-		if arg[0] == '-' {
-			continue
-		}
-		if i > 0 && args[i-1][0] == '-' {
-			f := fs.Lookup(strings.ReplaceAll(args[i-1], "-", ""))
+	return fs.Args()
+}
 
-			if f != nil && reflect.TypeOf(f.Value).Elem().Kind() != reflect.Bool {
-				continue
-			}
+// defineFlag defines a flag for given flag set. if shorthand parameter is not
+// an empty string and name parameter is not the same, additional flag is
+// defined for the shorthand.
+func defineFlag[T any](fs *FlagSet[any], name string, shorthand string, value T, usage string, opts ...Option) *T {
+	// Nil values only define the usage of the flag
+	if any(value) == nil {
+		if name == shorthand {
+			shorthand = ""
 		}
-		pArgs = append(pArgs, arg)
+		defineInfo(fs, name, shorthand, usage, newFlagConfig(opts))
+		return nil
 	}
 
-	return pArgs
+	p := new(T)
+	*p = value
+	defineVar(fs, p, name, shorthand, usage, opts...)
+	return p
 }
 
-// defineFlag defines a flag for given flag set. if shorthand parameter is not
-// an empty string and name parameter is not the same, additional flag is
-// defined for the shorthand.
-func defineFlag[T any](fs *FlagSet[any], name string, shorthand string, value T, usage string) *T {
+// defineVar defines a flag like defineFlag, but stores the value of the flag
+// in p, which must be a pointer. The value p points to is the default value of
+// the flag.
+func defineVar(fs *FlagSet[any], p any, name string, shorthand string, usage string, opts ...Option) {
 	if name == shorthand {
 		shorthand = ""
 	}
 
+	cfg := newFlagConfig(opts)
+	rv := reflect.ValueOf(p).Elem()
+
+	info := defineInfo(fs, name, shorthand, usage, cfg)
+	switch p.(type) {
+	case *bool:
+		info.Negatable = cfg.negatable
+	case *Counter:
+		// Counters do not take a value, so they have no value in the usage
+		info.UsageValue = ""
+	}
+
+	fs.addValidators(name, rv, cfg.validators)
+	if c, ok := p.(choicesValue); ok {
+		fs.addChoices(name, c.choices())
+	}
+
+	if tp, ok := lookupTypeOf(fs, rv.Type()); ok {
+		reflectVar(fs, rv, name, shorthand, usage, tp.parseAny, func(rv reflect.Value) string {
+			return tp.formatAny(rv.Interface())
+		})
+		return
+	}
+
+	switch v := p.(type) {
+	case *bool:
+		boolVar(fs, v, name, shorthand, usage)
+	case *string:
+		stringVar(fs, v, name, shorthand, usage)
+	case *int:
+		intVar(fs, v, name, shorthand, usage)
+	case *int64:
+		int64Var(fs, v, name, shorthand, usage)
+	case *uint:
+		uintVar(fs, v, name, shorthand, usage)
+	case *uint64:
+		uint64Var(fs, v, name, shorthand, usage)
+	case *float64:
+		float64Var(fs, v, name, shorthand, usage)
+	case *time.Duration:
+		durationVar(fs, v, name, shorthand, usage)
+	case *int8:
+		funcVar(fs, v, name, shorthand, usage, parseIntN[int8](8), formatValue[int8])
+	case *int16:
+		funcVar(fs, v, name, shorthand, usage, parseIntN[int16](16), formatValue[int16])
+	case *int32:
+		funcVar(fs, v, name, shorthand, usage, parseIntN[int32](32), formatValue[int32])
+	case *uint8:
+		funcVar(fs, v, name, shorthand, usage, parseUintN[uint8](8), formatValue[uint8])
+	case *uint16:
+		funcVar(fs, v, name, shorthand, usage, parseUintN[uint16](16), formatValue[uint16])
+	case *uint32:
+		funcVar(fs, v, name, shorthand, usage, parseUintN[uint32](32), formatValue[uint32])
+	case *float32:
+		funcVar(fs, v, name, shorthand, usage, parseFloat32, formatValue[float32])
+	case *complex128:
+		funcVar(fs, v, name, shorthand, usage, parseComplex128, formatValue[complex128])
+	case *[]string:
+		sliceVar(fs, v, name, shorthand, usage, parseString)
+	case *[]bool:
+		sliceVar(fs, v, name, shorthand, usage, parseBool)
+	case *[]int:
+		sliceVar(fs, v, name, shorthand, usage, parseInt)
+	case *[]int64:
+		sliceVar(fs, v, name, shorthand, usage, parseInt64)
+	case *[]uint:
+		sliceVar(fs, v, name, shorthand, usage, parseUint)
+	case *[]float64:
+		sliceVar(fs, v, name, shorthand, usage, parseFloat64)
+	case *[]time.Duration:
+		sliceVar(fs, v, name, shorthand, usage, parseDuration)
+	case *map[string]string:
+		mapVar(fs, v, name, shorthand, usage, parseString, cfg.keyPolicy)
+	case *map[string]bool:
+		mapVar(fs, v, name, shorthand, usage, parseBool, cfg.keyPolicy)
+	case *map[string]int:
+		mapVar(fs, v, name, shorthand, usage, parseInt, cfg.keyPolicy)
+	case *map[string]int64:
+		mapVar(fs, v, name, shorthand, usage, parseInt64, cfg.keyPolicy)
+	case *map[string]uint:
+		mapVar(fs, v, name, shorthand, usage, parseUint, cfg.keyPolicy)
+	case *map[string]float64:
+		mapVar(fs, v, name, shorthand, usage, parseFloat64, cfg.keyPolicy)
+	case *map[string]time.Duration:
+		mapVar(fs, v, name, shorthand, usage, parseDuration, cfg.keyPolicy)
+	default:
+		if v, ok := p.(flag.Value); ok {
+			valueVar(fs, v, name, shorthand, usage)
+			return
+		}
+		// NOTE: flag.TextVar is not available in go 1.18.
+		if _, ok := p.(encoding.TextUnmarshaler); ok {
+			reflectVar(fs, rv, name, shorthand, usage, parseText(rv.Type()), formatText)
+			return
+		}
+		panic(fmt.Sprintf("unsupported type %s for flag %s: implement flag.Value or register the type with RegisterType", rv.Type(), name))
+	}
+}
+
+// defineInfo adds the flag information used in the help usage and returns
+// it for further configuration.
+func defineInfo(fs *FlagSet[any], name string, shorthand string, usage string, cfg flagConfig) *flagInfo {
 	defineUsage(&fs.flags, name, shorthand, usage)
 
-	switch v := any(value).(type) {
-	case bool:
-		return any((boolVar(fs, name, shorthand, v, usage))).(*T)
-	case string:
-		return any(stringVar(fs, name, shorthand, v, usage)).(*T)
-	case int:
-		return any(intVar(fs, name, shorthand, v, usage)).(*T)
-	case int64:
-		return any(int64Var(fs, name, shorthand, v, usage)).(*T)
-	case uint:
-		return any(uintVar(fs, name, shorthand, v, usage)).(*T)
-	case uint64:
-		return any(uint64Var(fs, name, shorthand, v, usage)).(*T)
-	case float64:
-		return any(float64Var(fs, name, shorthand, v, usage)).(*T)
-	case time.Duration:
-		return any(durationVar(fs, name, shorthand, v, usage)).(*T)
-	case T:
-		return valueVar(fs, name, shorthand, v, usage)
-	}
-	return nil
+	info := &fs.flags[len(fs.flags)-1]
+	info.Env = cfg.env
+	info.Required = cfg.required
+	info.Persistent = cfg.persistent
+	if cfg.noValue != nil {
+		info.OptionalValue = true
+		info.NoValue = *cfg.noValue
+	}
+	return info
+}
+
+// usageRow is a row of the help usage listing a flag or a command.
+type usageRow struct {
+	name string
+	text string
+}
+
+func writeUsageRows(w io.Writer, rows []usageRow, width int) {
+	for _, r := range rows {
+		fmt.Fprintf(w, "%*s%*s\n", len(r.name)+4, r.name, len(r.text)-len(r.name)+width, r.text)
+	}
 }
 
 func usage[T any](fs *FlagSet[T]) {
 	var s, u strings.Builder
 
-	s.WriteString("usage: " + fs.Name())
+	name := fs.Name()
+	if fs.command != nil {
+		name = fs.command.path()
+		if fs.command.Long != "" {
+			fmt.Fprintf(fs.Output(), "%s\n\n", fs.command.Long)
+		}
+	}
+	s.WriteString("usage: " + name)
 
 	p := s.Len()
+	n := 0
+	var rows []usageRow
 
-	for i, f := range fs.flags {
-		var c strings.Builder
-
+	for _, f := range fs.flags {
 		if f.Shorthand == "" && f.Longhand == "" {
 			continue
 		}
 
-		if f.Shorthand != "" {
-			fmt.Fprintf(&c, "-%s", f.Shorthand)
-		}
+		for _, item := range fs.synopsis(f) {
+			fmt.Fprintf(&s, " %s", item)
 
-		if f.Longhand != "" {
-			if f.Shorthand != "" {
-				c.WriteRune(' ')
+			n++
+			if n%4 == 0 {
+				fmt.Fprintf(&s, "\n%*s", p, "")
 			}
-			fmt.Fprintf(&c, "--%s", f.Longhand)
 		}
 
-		compound := c.String()
+		text := f.Usage
+		if f.Required {
+			text += " [required]"
+		}
+		if env := fs.envName(f); env != "" {
+			text += " [env: " + env + "]"
+		}
+		rows = append(rows, usageRow{compoundName(f), text})
+	}
 
-		if f.UsageValue != "" {
-			fmt.Fprintf(&s, " [%s=%s]", compound, f.UsageValue)
-		} else {
-			fmt.Fprintf(&s, " [%s]", compound)
+	var commands []usageRow
+	if fs.command != nil && len(fs.command.children) > 0 {
+		s.WriteString(" <command>")
+		for _, c := range fs.command.children {
+			commands = append(commands, usageRow{strings.Join(c.names(), ", "), c.Short})
 		}
+	}
 
-		if (i+1)%4 == 0 {
-			fmt.Fprintf(&s, "\n%*s", p, "")
+	// The descriptions start at the same column, leaving at least one
+	// space after the longest name
+	width := 16
+	for _, r := range append(rows, commands...) {
+		if len(r.name) >= width {
+			width = len(r.name) + 1
 		}
+	}
 
-		fmt.Fprintf(
-			&u,
-			"%*s%*s\n",
-			len(compound)+4,
-			compound,
-			len(f.Usage)-len(compound)+16,
-			f.Usage,
-		)
+	writeUsageRows(&u, rows, width)
+	if len(commands) > 0 {
+		u.WriteString("commands:\n")
+		writeUsageRows(&u, commands, width)
 	}
 
 	fmt.Fprint(fs.Output(), s.String(), "\n", u.String())
 }
 
+// synopsis returns the items shown for the flag in the first line of the
+// help usage. Flags in groups are shown as part of their groups, which are
+// shown in place of the first flag of the group.
+func (fs *FlagSet[T]) synopsis(f flagInfo) []string {
+	var items []string
+	grouped := false
+	for _, g := range fs.groups {
+		if !g.contains(f.Longhand) {
+			continue
+		}
+		grouped = true
+		if g.names[0] == f.Longhand {
+			items = append(items, fs.groupSynopsis(g))
+		}
+	}
+
+	if grouped {
+		return items
+	}
+	// Required flags are not optional, so they are shown without brackets
+	if f.Required {
+		return []string{flagSynopsis(f)}
+	}
+	return []string{"[" + flagSynopsis(f) + "]"}
+}
+
+// flagSynopsis returns the names and the value of the flag, e.g.
+// -p --port=number.
+func flagSynopsis(f flagInfo) string {
+	compound := compoundName(f)
+	if f.OptionalValue {
+		value := f.UsageValue
+		if value == "" {
+			value = "value"
+		}
+		return fmt.Sprintf("%s[=%s]", compound, value)
+	}
+	if f.UsageValue != "" {
+		return fmt.Sprintf("%s=%s", compound, f.UsageValue)
+	}
+	return compound
+}
+
+// compoundName returns the shorthand and the name of the flag, e.g. -p --port.
+func compoundName(f flagInfo) string {
+	var c strings.Builder
+
+	if f.Shorthand != "" {
+		fmt.Fprintf(&c, "-%s", f.Shorthand)
+	}
+
+	if f.Longhand != "" {
+		if f.Shorthand != "" {
+			c.WriteRune(' ')
+		}
+		if f.Negatable {
+			fmt.Fprintf(&c, "--[no-]%s", f.Longhand)
+		} else {
+			fmt.Fprintf(&c, "--%s", f.Longhand)
+		}
+	}
+
+	return c.String()
+}
+
 func defineUsage(flags *[]flagInfo, name string, shorthand string, usage string) {
 	*flags = append(
 		*flags,
@@ -283,74 +523,65 @@ func extractUsageValue(s string) string {
 	return ""
 }
 
-func boolVar(fs *FlagSet[any], name string, shorthand string, value bool, usage string) *bool {
-	fs.BoolVar(&value, name, value, usage)
+func boolVar(fs *FlagSet[any], p *bool, name string, shorthand string, usage string) {
+	fs.BoolVar(p, name, *p, usage)
 	if shorthand != "" {
-		fs.BoolVar(&value, shorthand, value, usage)
+		fs.BoolVar(p, shorthand, *p, usage)
 	}
-	return &value
 }
 
-func stringVar(fs *FlagSet[any], name string, shorthand string, value string, usage string) *string {
-	fs.StringVar(&value, name, value, usage)
+func stringVar(fs *FlagSet[any], p *string, name string, shorthand string, usage string) {
+	fs.StringVar(p, name, *p, usage)
 	if shorthand != "" {
-		fs.StringVar(&value, shorthand, value, usage)
+		fs.StringVar(p, shorthand, *p, usage)
 	}
-	return &value
 }
 
-func intVar(fs *FlagSet[any], name string, shorthand string, value int, usage string) *int {
-	fs.IntVar(&value, name, value, usage)
+func intVar(fs *FlagSet[any], p *int, name string, shorthand string, usage string) {
+	fs.IntVar(p, name, *p, usage)
 	if shorthand != "" {
-		fs.IntVar(&value, shorthand, value, usage)
+		fs.IntVar(p, shorthand, *p, usage)
 	}
-	return &value
 }
 
-func int64Var(fs *FlagSet[any], name string, shorthand string, value int64, usage string) *int64 {
-	fs.Int64Var(&value, name, value, usage)
+func int64Var(fs *FlagSet[any], p *int64, name string, shorthand string, usage string) {
+	fs.Int64Var(p, name, *p, usage)
 	if shorthand != "" {
-		fs.Int64Var(&value, shorthand, value, usage)
+		fs.Int64Var(p, shorthand, *p, usage)
 	}
-	return &value
 }
 
-func uintVar(fs *FlagSet[any], name string, shorthand string, value uint, usage string) *uint {
-	fs.UintVar(&value, name, value, usage)
+func uintVar(fs *FlagSet[any], p *uint, name string, shorthand string, usage string) {
+	fs.UintVar(p, name, *p, usage)
 	if shorthand != "" {
-		fs.UintVar(&value, shorthand, value, usage)
+		fs.UintVar(p, shorthand, *p, usage)
 	}
-	return &value
 }
 
-func uint64Var(fs *FlagSet[any], name string, shorthand string, value uint64, usage string) *uint64 {
-	fs.Uint64Var(&value, name, value, usage)
+func uint64Var(fs *FlagSet[any], p *uint64, name string, shorthand string, usage string) {
+	fs.Uint64Var(p, name, *p, usage)
 	if shorthand != "" {
-		fs.Uint64Var(&value, shorthand, value, usage)
+		fs.Uint64Var(p, shorthand, *p, usage)
 	}
-	return &value
 }
 
-func float64Var(fs *FlagSet[any], name string, shorthand string, value float64, usage string) *float64 {
-	fs.Float64Var(&value, name, value, usage)
+func float64Var(fs *FlagSet[any], p *float64, name string, shorthand string, usage string) {
+	fs.Float64Var(p, name, *p, usage)
 	if shorthand != "" {
-		fs.Float64Var(&value, shorthand, value, usage)
+		fs.Float64Var(p, shorthand, *p, usage)
 	}
-	return &value
 }
 
-func durationVar(fs *FlagSet[any], name string, shorthand string, value time.Duration, usage string) *time.Duration {
-	fs.DurationVar(&value, name, value, usage)
+func durationVar(fs *FlagSet[any], p *time.Duration, name string, shorthand string, usage string) {
+	fs.DurationVar(p, name, *p, usage)
 	if shorthand != "" {
-		fs.DurationVar(&value, shorthand, value, usage)
+		fs.DurationVar(p, shorthand, *p, usage)
 	}
-	return &value
 }
 
-func valueVar[T any](fs *FlagSet[any], name string, shorthand string, value T, usage string) *T {
-	fs.Var(any(&value).(flag.Value), name, usage)
+func valueVar(fs *FlagSet[any], v flag.Value, name string, shorthand string, usage string) {
+	fs.Var(v, name, usage)
 	if shorthand != "" {
-		fs.Var(any(&value).(flag.Value), shorthand, usage)
+		fs.Var(v, shorthand, usage)
 	}
-	return &value
 }
@@ -3,6 +3,8 @@ package miniflag
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 	"time"
 )
@@ -39,6 +41,45 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseSubcommand(t *testing.T) {
+	tests := [][]string{
+		{"-v", "sub", "-b"},
+		{"sub", "-b", "-v"},
+	}
+
+	for _, args := range tests {
+		fs := newFlagSet("tool", ContinueOnError)
+		sub := newFlagSet("sub", ContinueOnError)
+		newRootCommand(fs).setCommand(&Command{Name: "sub", Flags: sub})
+		t.Run("", func(t *testing.T) {
+			verbose := SetFlag(fs, "verbose", "v", false, "", Persistent())
+			b := SetFlag(sub, "b", "", false, "")
+
+			if err := parse(fs, args); err != nil {
+				t.Fatal(err)
+			}
+
+			if !*verbose || !*b {
+				t.Fatalf("flag values did not match expected true true, got %t %t", *verbose, *b)
+			}
+		})
+	}
+}
+
+func TestFlagSetFlags(t *testing.T) {
+	a := NewFlagSet("a", ContinueOnError)
+	b := NewFlagSet("b", ContinueOnError)
+	SetFlag(a, "alpha", "a", false, "")
+	SetFlag(b, "beta", "b", false, "")
+
+	if len(a.flags) != 1 || a.flags[0].Longhand != "alpha" {
+		t.Fatalf("flag set flags did not match expected alpha, got %v", a.flags)
+	}
+	if len(b.flags) != 1 || b.flags[0].Longhand != "beta" {
+		t.Fatalf("flag set flags did not match expected beta, got %v", b.flags)
+	}
+}
+
 func TestNameIsShorthand(t *testing.T) {
 	tests := []struct {
 		args     []string
@@ -240,6 +281,198 @@ func TestUint(t *testing.T) {
 	}
 }
 
+func TestInt8(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected int8
+		err      string
+	}{
+		{
+			expected: 0,
+		},
+		{
+			args:     []string{"-i", "-128"},
+			expected: -128,
+		},
+		{
+			args:     []string{"--int8", "0x7f"},
+			expected: 127,
+		},
+		{
+			args: []string{"--int8", "128"},
+			err:  "value 128 out of range for int8",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "int8", "i", int8(0), "Test int8 flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %d, got %d", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestUint8(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected uint8
+		err      string
+	}{
+		{
+			expected: 0,
+		},
+		{
+			args:     []string{"-u", "0b101"},
+			expected: 5,
+		},
+		{
+			args:     []string{"--uint8", "0o17"},
+			expected: 15,
+		},
+		{
+			args: []string{"--uint8", "300"},
+			err:  "value 300 out of range for uint8",
+		},
+		{
+			args: []string{"--uint8", "-1"},
+			err:  "parse error",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "uint8", "u", uint8(0), "Test uint8 flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %d, got %d", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestSizedInts(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	i16 := SetFlag(fs, "int16", "", int16(0), "Test int16 flag")
+	i32 := SetFlag(fs, "int32", "", int32(0), "Test int32 flag")
+	u16 := SetFlag(fs, "uint16", "", uint16(0), "Test uint16 flag")
+	u32 := SetFlag(fs, "uint32", "", uint32(0), "Test uint32 flag")
+
+	args := []string{"--int16", "-32768", "--int32", "2147483647", "--uint16", "0xffff", "--uint32", "4294967295"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	if *i16 != -32768 || *i32 != 2147483647 || *u16 != 65535 || *u32 != 4294967295 {
+		t.Fatalf("flag values did not match expected, got %d %d %d %d", *i16, *i32, *u16, *u32)
+	}
+}
+
+func TestFloat32(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected float32
+		err      bool
+	}{
+		{
+			expected: 0,
+		},
+		{
+			args:     []string{"-f", "1.5"},
+			expected: 1.5,
+		},
+		{
+			args: []string{"--float32", "1e39"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "float32", "f", float32(0), "Test float32 flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected parse error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %f, got %f", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestComplex128(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected complex128
+	}{
+		{
+			expected: 0,
+		},
+		{
+			args:     []string{"-c", "1+2i"},
+			expected: complex(1, 2),
+		},
+		{
+			args:     []string{"--complex128", "(-1.5-0.5i)"},
+			expected: complex(-1.5, -0.5),
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "complex128", "c", complex128(0), "Test complex128 flag")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %v, got %v", tt.expected, *actual)
+			}
+		})
+	}
+}
+
 func TestNil(t *testing.T) {
 	tests := []struct {
 		expected any
@@ -486,6 +719,53 @@ func TestUsage(t *testing.T) {
 			},
 			expected: "usage: test [-t --test=<test>]\n    -t --test       Usage for `<test>`\n",
 		},
+		{
+			flags: []flagInfo{
+				{
+					Longhand:  "cache",
+					Shorthand: "c",
+					Usage:     "Usage for cache",
+					Negatable: true,
+				},
+			},
+			expected: "usage: test [-c --[no-]cache]\n    -c --[no-]cache Usage for cache\n",
+		},
+		{
+			flags: []flagInfo{
+				{
+					Longhand:  "verbose",
+					Shorthand: "v",
+					Usage:     "Usage for verbose",
+					Negatable: true,
+				},
+				{
+					Longhand:  "name",
+					Shorthand: "n",
+					Usage:     "Usage for name",
+				},
+			},
+			expected: `usage: test [-v --[no-]verbose] [-n --name]
+    -v --[no-]verbose Usage for verbose
+    -n --name         Usage for name
+`,
+		},
+		{
+			flags: []flagInfo{
+				{
+					Longhand:      "color",
+					Usage:         "Colorize `<when>`",
+					UsageValue:    "<when>",
+					OptionalValue: true,
+					NoValue:       "always",
+				},
+				{
+					Longhand:      "level",
+					Usage:         "Usage for level",
+					OptionalValue: true,
+				},
+			},
+			expected: "usage: test [--color[=<when>]] [--level[=value]]\n    --color         Colorize `<when>`\n    --level         Usage for level\n",
+		},
 		{
 			flags: []flagInfo{
 				{
@@ -572,7 +852,7 @@ func TestDefineUsage(t *testing.T) {
 			actual := tt.actual[0]
 
 			if tt.expected != actual {
-				t.Fatalf("flag usage did not match expected %q, got %q", tt.expected, actual)
+				t.Fatalf("flag usage did not match expected %+v, got %+v", tt.expected, actual)
 			}
 		})
 	}
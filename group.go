@@ -0,0 +1,129 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupKind defines the constraint of a flag group.
+type GroupKind int
+
+// These constants define the constraint of a flag group.
+const (
+	MutuallyExclusive GroupKind = iota // At most one of the flags can be set.
+	AtLeastOne                         // At least one of the flags must be set.
+	ExactlyOne                         // Exactly one of the flags must be set.
+	AllOrNone                          // Either all or none of the flags must be set.
+)
+
+// GroupError is the error returned by Parse when the flags of a group violate
+// its constraint. Flags holds all flags of the group and Set the flags that
+// were set, e.g. --json.
+type GroupError struct {
+	Kind  GroupKind
+	Flags []string
+	Set   []string
+}
+
+func (e *GroupError) Error() string {
+	switch e.Kind {
+	case AtLeastOne:
+		return "at least one of the flags " + strings.Join(e.Flags, ", ") + " is required"
+	case ExactlyOne:
+		if len(e.Set) == 0 {
+			return "exactly one of the flags " + strings.Join(e.Flags, ", ") + " is required"
+		}
+	case AllOrNone:
+		return "flags " + strings.Join(e.Flags, ", ") + " must be used together"
+	}
+	return "flags " + strings.Join(e.Set, ", ") + " cannot be used together"
+}
+
+// flagGroup holds the names of the flags in a group.
+type flagGroup struct {
+	kind  GroupKind
+	names []string
+}
+
+func (g flagGroup) contains(name string) bool {
+	for _, n := range g.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Group declares a group of flags with the given constraint, which is checked
+// after parsing. The flags are given by their names or shorthands and must be
+// defined before the group. The help usage shows the group in place of its
+// first flag, e.g. [--json | --yaml] for mutually exclusive flags. Group
+// panics if a flag is not defined.
+func (fs *FlagSet[T]) Group(kind GroupKind, names ...string) {
+	g := flagGroup{kind: kind, names: make([]string, len(names))}
+	for i, name := range names {
+		info := fs.lookupInfo(name)
+		if info == nil {
+			panic(fmt.Sprintf("flag %s in group is not defined", name))
+		}
+		g.names[i] = info.Longhand
+	}
+	fs.groups = append(fs.groups, g)
+}
+
+// checkGroups returns a GroupError for the first group whose constraint is
+// violated by the flags set from any source.
+func (fs *FlagSet[T]) checkGroups() error {
+	for _, g := range fs.groups {
+		var flags, set []string
+		for _, name := range g.names {
+			flags = append(flags, "--"+name)
+			if fs.Changed(name) {
+				set = append(set, "--"+name)
+			}
+		}
+
+		var ok bool
+		switch g.kind {
+		case MutuallyExclusive:
+			ok = len(set) <= 1
+		case AtLeastOne:
+			ok = len(set) >= 1
+		case ExactlyOne:
+			ok = len(set) == 1
+		case AllOrNone:
+			ok = len(set) == 0 || len(set) == len(flags)
+		}
+		if !ok {
+			return &GroupError{Kind: g.kind, Flags: flags, Set: set}
+		}
+	}
+
+	return nil
+}
+
+// groupSynopsis returns the group as shown in the help usage:
+// [a | b] for mutually exclusive flags, (a | b)... for at least one of the
+// flags, (a | b) for exactly one of the flags and [a b] for all or none of
+// the flags.
+func (fs *FlagSet[T]) groupSynopsis(g flagGroup) string {
+	items := make([]string, 0, len(g.names))
+	for _, name := range g.names {
+		if info := fs.lookupInfo(name); info != nil {
+			items = append(items, flagSynopsis(*info))
+		}
+	}
+
+	switch g.kind {
+	case AtLeastOne:
+		return "(" + strings.Join(items, " | ") + ")..."
+	case ExactlyOne:
+		return "(" + strings.Join(items, " | ") + ")"
+	case AllOrNone:
+		return "[" + strings.Join(items, " ") + "]"
+	}
+	return "[" + strings.Join(items, " | ") + "]"
+}
@@ -0,0 +1,152 @@
+package miniflag
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		kind GroupKind
+		args []string
+		env  map[string]string
+		err  string
+	}{
+		{
+			kind: MutuallyExclusive,
+		},
+		{
+			kind: MutuallyExclusive,
+			args: []string{"--json"},
+		},
+		{
+			kind: MutuallyExclusive,
+			args: []string{"--json", "-y"},
+			err:  "flags --json, --yaml cannot be used together",
+		},
+		{
+			kind: MutuallyExclusive,
+			args: []string{"--json"},
+			env:  map[string]string{"YAML": "true"},
+			err:  "flags --json, --yaml cannot be used together",
+		},
+		{
+			kind: AtLeastOne,
+			err:  "at least one of the flags --json, --yaml is required",
+		},
+		{
+			kind: AtLeastOne,
+			args: []string{"--json", "--yaml"},
+		},
+		{
+			kind: ExactlyOne,
+			err:  "exactly one of the flags --json, --yaml is required",
+		},
+		{
+			kind: ExactlyOne,
+			args: []string{"--yaml"},
+		},
+		{
+			kind: ExactlyOne,
+			args: []string{"--json", "--yaml"},
+			err:  "flags --json, --yaml cannot be used together",
+		},
+		{
+			kind: AllOrNone,
+		},
+		{
+			kind: AllOrNone,
+			args: []string{"--json", "--yaml"},
+		},
+		{
+			kind: AllOrNone,
+			args: []string{"--yaml"},
+			err:  "flags --json, --yaml must be used together",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			SetFlag(fs, "json", "j", false, "json flag")
+			SetFlag(fs, "yaml", "y", false, "yaml flag", Env("YAML"))
+			fs.Group(tt.kind, "json", "y")
+
+			err := fs.Parse(tt.args)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var groupErr *GroupError
+			if !errors.As(err, &groupErr) || groupErr.Kind != tt.kind || tt.err != err.Error() {
+				t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestGroupUndefined(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	SetFlag(fs, "json", "j", false, "json flag")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	fs.Group(MutuallyExclusive, "json", "yaml")
+}
+
+func TestGroupUsage(t *testing.T) {
+	tests := []struct {
+		kind     GroupKind
+		expected string
+	}{
+		{
+			kind:     MutuallyExclusive,
+			expected: "usage: test [--json | --yaml] [-o --out=file]\n",
+		},
+		{
+			kind:     AtLeastOne,
+			expected: "usage: test (--json | --yaml)... [-o --out=file]\n",
+		},
+		{
+			kind:     ExactlyOne,
+			expected: "usage: test (--json | --yaml) [-o --out=file]\n",
+		},
+		{
+			kind:     AllOrNone,
+			expected: "usage: test [--json --yaml] [-o --out=file]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var b bytes.Buffer
+		fs := NewFlagSet("test", ContinueOnError)
+		fs.SetOutput(&b)
+		t.Run("", func(t *testing.T) {
+			SetFlag(fs, "json", "", false, "Usage for json")
+			SetFlag(fs, "out", "o", "", "Usage for `file`")
+			SetFlag(fs, "yaml", "", false, "Usage for yaml")
+			fs.Group(tt.kind, "json", "yaml")
+			fs.Usage()
+
+			expected := tt.expected + `    --json          Usage for json
+    -o --out        Usage for ` + "`file`" + `
+    --yaml          Usage for yaml
+`
+			if actual := b.String(); expected != actual {
+				t.Fatalf("Help string did not match expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
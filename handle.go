@@ -0,0 +1,95 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+// Handle is a typed handle to a defined flag. Unlike the pointer returned by
+// Flag and SetFlag, a handle keeps the metadata of the flag, so it can be
+// passed around without looking the flag up by name.
+type Handle[T any] struct {
+	fs        *FlagSet[any]
+	name      string
+	shorthand string
+	value     *T
+	def       T
+}
+
+// resetter is implemented by flag values that track their own state, such as
+// slice and map values, which replace the default value on the first Set.
+type resetter interface {
+	reset()
+}
+
+// SetFlagHandle defines a new flag to a given FlagSet like SetFlag, but
+// returns a handle to the flag instead of a pointer to its value.
+func SetFlagHandle[T, C any](fs *FlagSet[C], name string, shorthand string, value T, usage string, opts ...Option) *Handle[T] {
+	return newHandle(fs.untyped(), name, value, defineFlag(fs.untyped(), name, shorthand, value, usage, opts...))
+}
+
+// FlagHandle defines a new flag for CommandLine like Flag, but returns a
+// handle to the flag instead of a pointer to its value.
+func FlagHandle[T any](name string, shorthand string, value T, usage string, opts ...Option) *Handle[T] {
+	return SetFlagHandle(CommandLine, name, shorthand, value, usage, opts...)
+}
+
+func newHandle[T any](fs *FlagSet[any], name string, value T, p *T) *Handle[T] {
+	h := &Handle[T]{fs: fs, name: name, value: p, def: value}
+	if info := fs.lookupInfo(name); info != nil {
+		h.shorthand = info.Shorthand
+	}
+	return h
+}
+
+// Get returns the current value of the flag.
+func (h *Handle[T]) Get() T {
+	return *h.value
+}
+
+// Ptr returns the pointer to the value of the flag, the same pointer that
+// SetFlag returns.
+func (h *Handle[T]) Ptr() *T {
+	return h.value
+}
+
+// Default returns the default value of the flag.
+func (h *Handle[T]) Default() T {
+	return h.def
+}
+
+// Name returns the name of the flag.
+func (h *Handle[T]) Name() string {
+	return h.name
+}
+
+// Shorthand returns the shorthand of the flag, or an empty string if the flag
+// has no shorthand.
+func (h *Handle[T]) Shorthand() string {
+	return h.shorthand
+}
+
+// Changed reports whether the flag has been set from any source. See
+// FlagSet.Changed.
+func (h *Handle[T]) Changed() bool {
+	return h.fs.Changed(h.name)
+}
+
+// Source returns where the value of the flag came from. See FlagSet.Source.
+func (h *Handle[T]) Source() Source {
+	return h.fs.Source(h.name)
+}
+
+// Set sets the value of the flag from its string form like FlagSet.Set.
+func (h *Handle[T]) Set(value string) error {
+	return h.fs.Set(h.name, value)
+}
+
+// Reset restores the default value of the flag and marks it as not changed.
+func (h *Handle[T]) Reset() {
+	*h.value = h.def
+	if f := h.fs.Lookup(h.name); f != nil {
+		if r, ok := f.Value.(resetter); ok {
+			r.reset()
+		}
+	}
+	delete(h.fs.sources, h.name)
+}
@@ -0,0 +1,72 @@
+package miniflag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	port := SetFlagHandle(fs, "port", "p", 8080, "port flag")
+	name := SetFlagHandle(fs, "name", "name", "", "name flag")
+
+	if port.Name() != "port" || port.Shorthand() != "p" {
+		t.Fatalf("handle did not match expected port p, got %s %s", port.Name(), port.Shorthand())
+	}
+	if name.Shorthand() != "" {
+		t.Fatalf("shorthand did not match expected empty string, got %q", name.Shorthand())
+	}
+
+	if err := fs.Parse([]string{"-p", "8080"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if port.Get() != 8080 || port.Default() != 8080 || !port.Changed() || port.Source() != SourceCommandLine {
+		t.Fatalf("handle did not match expected changed 8080, got %d %t", port.Get(), port.Changed())
+	}
+	if name.Changed() {
+		t.Fatal("expected name flag to be unchanged")
+	}
+
+	if err := port.Set("9090"); err != nil {
+		t.Fatal(err)
+	}
+	if port.Get() != 9090 || *port.Ptr() != 9090 || port.Source() != SourceSet {
+		t.Fatalf("flag value did not match expected 9090, got %d", port.Get())
+	}
+
+	port.Reset()
+	if port.Get() != 8080 || port.Changed() {
+		t.Fatalf("handle did not match expected unchanged 8080, got %d %t", port.Get(), port.Changed())
+	}
+}
+
+func TestHandleReset(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	tags := SetFlagHandle(fs, "tag", "t", []string{"latest"}, "tag flag")
+	labels := SetFlagHandle(fs, "label", "l", map[string]string{"env": "dev"}, "label flag")
+
+	if err := fs.Parse([]string{"-t", "a", "-t", "b", "-l", "env=prod"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(expected, tags.Get()) {
+		t.Fatalf("flag value did not match expected %q, got %q", expected, tags.Get())
+	}
+
+	tags.Reset()
+	labels.Reset()
+	if expected := []string{"latest"}; !reflect.DeepEqual(expected, tags.Get()) {
+		t.Fatalf("flag value did not match expected %q, got %q", expected, tags.Get())
+	}
+	if expected := map[string]string{"env": "dev"}; !reflect.DeepEqual(expected, labels.Get()) {
+		t.Fatalf("flag value did not match expected %v, got %v", expected, labels.Get())
+	}
+
+	// The default value is replaced again after a reset
+	if err := tags.Set("c"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"c"}; !reflect.DeepEqual(expected, tags.Get()) {
+		t.Fatalf("flag value did not match expected %q, got %q", expected, tags.Get())
+	}
+}
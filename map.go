@@ -0,0 +1,104 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DuplicateKeyPolicy defines how a map flag behaves when the same key is
+// given more than once.
+type DuplicateKeyPolicy int
+
+// These constants define the duplicate key policy of a map flag.
+const (
+	LastKeyWins       DuplicateKeyPolicy = iota // Overwrite the earlier value.
+	DuplicateKeyError                           // Fail with a descriptive error.
+)
+
+// mapValue is a repeatable flag value that collects key=value pairs into a
+// map. A single argument may hold multiple comma separated pairs. The default
+// map is replaced on the first Set instead of being merged into.
+type mapValue[T any] struct {
+	value   *map[string]T
+	parse   func(string) (T, error)
+	policy  DuplicateKeyPolicy
+	changed bool
+}
+
+func (m *mapValue[T]) Set(value string) error {
+	var pairs []string
+	if value != "" {
+		pairs = strings.Split(value, ",")
+	}
+	return m.add(pairs)
+}
+
+// addElement adds a single key=value pair without splitting it on commas.
+func (m *mapValue[T]) addElement(pair string) error {
+	return m.add([]string{pair})
+}
+
+func (m *mapValue[T]) add(pairs []string) error {
+	if !m.changed {
+		*m.value = make(map[string]T)
+		m.changed = true
+	}
+
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+
+		if _, ok := (*m.value)[k]; ok && m.policy == DuplicateKeyError {
+			return fmt.Errorf("duplicate key %q", k)
+		}
+
+		p, err := m.parse(v)
+		if err != nil {
+			return err
+		}
+		(*m.value)[k] = p
+	}
+
+	return nil
+}
+
+func (m *mapValue[T]) reset() {
+	m.changed = false
+}
+
+func (m *mapValue[T]) Get() any {
+	return *m.value
+}
+
+func (m *mapValue[T]) String() string {
+	// The flag package may call String on a zero value
+	if m.value == nil {
+		return "[]"
+	}
+
+	keys := make([]string, 0, len(*m.value))
+	for k := range *m.value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, (*m.value)[k])
+	}
+	return "[" + strings.Join(pairs, ",") + "]"
+}
+
+func mapVar[T any](fs *FlagSet[any], p *map[string]T, name string, shorthand string, usage string, parse func(string) (T, error), policy DuplicateKeyPolicy) {
+	v := &mapValue[T]{value: p, parse: parse, policy: policy}
+	fs.Var(v, name, usage)
+	if shorthand != "" {
+		fs.Var(v, shorthand, usage)
+	}
+}
@@ -0,0 +1,138 @@
+package miniflag
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestStringMap(t *testing.T) {
+	tests := []struct {
+		args     []string
+		value    map[string]string
+		expected map[string]string
+	}{
+		{
+			value:    map[string]string{"env": "dev"},
+			expected: map[string]string{"env": "dev"},
+		},
+		{
+			args:     []string{"-l", "env=prod", "--label", "team=core"},
+			value:    map[string]string{"env": "dev"},
+			expected: map[string]string{"env": "prod", "team": "core"},
+		},
+		{
+			args:     []string{"--label", "env=prod,team=core"},
+			value:    map[string]string{},
+			expected: map[string]string{"env": "prod", "team": "core"},
+		},
+		{
+			args:     []string{"-l", "env=prod", "-l", "env=test"},
+			value:    map[string]string{},
+			expected: map[string]string{"env": "test"},
+		},
+		{
+			args:     []string{"-l", "url=a=b"},
+			value:    map[string]string{},
+			expected: map[string]string{"url": "a=b"},
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "label", "l", tt.value, "Test string map flag")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, *actual) {
+				t.Fatalf("flag value did not match expected %v, got %v", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestIntMap(t *testing.T) {
+	tests := []struct {
+		args     []string
+		opts     []Option
+		expected map[string]int
+		err      bool
+	}{
+		{
+			args:     []string{"-w", "a=1,b=0x2"},
+			expected: map[string]int{"a": 1, "b": 2},
+		},
+		{
+			args: []string{"-w", "a=x"},
+			err:  true,
+		},
+		{
+			args: []string{"-w", "a"},
+			err:  true,
+		},
+		{
+			args:     []string{"-w", "a=1", "-w", "a=2"},
+			opts:     []Option{OnDuplicateKey(LastKeyWins)},
+			expected: map[string]int{"a": 2},
+		},
+		{
+			args: []string{"-w", "a=1", "-w", "a=2"},
+			opts: []Option{OnDuplicateKey(DuplicateKeyError)},
+			err:  true,
+		},
+		{
+			args: []string{"-w", "a=1,a=2"},
+			opts: []Option{OnDuplicateKey(DuplicateKeyError)},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "weight", "w", map[string]int{"a": 1}, "Test int map flag", tt.opts...)
+
+			err := fs.Parse(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected parse error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, *actual) {
+				t.Fatalf("flag value did not match expected %v, got %v", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestMapString(t *testing.T) {
+	tests := []struct {
+		value    mapValue[int]
+		expected string
+	}{
+		{
+			expected: "[]",
+		},
+		{
+			value:    mapValue[int]{value: &map[string]int{"b": 2, "a": 1}},
+			expected: "[a=1,b=2]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if actual := tt.value.String(); tt.expected != actual {
+				t.Fatalf("map string did not match expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
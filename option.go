@@ -0,0 +1,84 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+// Option configures a single flag. Options are given as the trailing
+// arguments of Flag and SetFlag. Options that do not apply to the type of the
+// flag are ignored, except for validators such as Range, which panic.
+type Option func(*flagConfig)
+
+// flagConfig holds the configuration collected from the options of a single
+// flag definition.
+type flagConfig struct {
+	keyPolicy  DuplicateKeyPolicy
+	negatable  bool
+	noValue    *string
+	env        string
+	required   bool
+	persistent bool
+	// validators are run after the flag is set
+	validators []validator
+}
+
+func newFlagConfig(opts []Option) flagConfig {
+	var cfg flagConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// OnDuplicateKey sets the policy for keys given more than once to a map flag.
+// By default the last value wins.
+func OnDuplicateKey(policy DuplicateKeyPolicy) Option {
+	return func(cfg *flagConfig) {
+		cfg.keyPolicy = policy
+	}
+}
+
+// Negatable defines a --no-<name> form for a bool flag, which sets the flag to
+// false. The help usage shows the flag as --[no-]<name>.
+func Negatable() Option {
+	return func(cfg *flagConfig) {
+		cfg.negatable = true
+	}
+}
+
+// OptionalValue makes the value of a flag optional. When the flag is given
+// without a value, e.g. --color instead of --color=never, the flag is set to
+// noValue. A value must then be given with "=" and the following argument is
+// never taken as the value. The help usage shows the flag as --<name>[=value].
+func OptionalValue(noValue string) Option {
+	return func(cfg *flagConfig) {
+		cfg.noValue = &noValue
+	}
+}
+
+// Env binds the flag to the environment variable with the given name. The
+// variable overrides the variable derived from the prefix of the flag set.
+// See FlagSet.SetEnvPrefix.
+func Env(name string) Option {
+	return func(cfg *flagConfig) {
+		cfg.env = name
+	}
+}
+
+// Required makes the flag required. Parse fails with a RequiredFlagsError if
+// the flag is not set on the command line, in the environment or in the config
+// file. The help usage marks the flag as required.
+func Required() Option {
+	return func(cfg *flagConfig) {
+		cfg.required = true
+	}
+}
+
+// Persistent makes the flag available to the subcommands of the flag set. A
+// persistent flag is accepted both before and after the name of a subcommand,
+// e.g. tool -v sub and tool sub -v, unless the subcommand defines a flag with
+// the same name.
+func Persistent() Option {
+	return func(cfg *flagConfig) {
+		cfg.persistent = true
+	}
+}
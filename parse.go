@@ -0,0 +1,446 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrHelp is the error returned if the -help or -h flag is invoked but no
+// such flag is defined.
+// NOTE: Direct reference to standard library.
+var ErrHelp = flag.ErrHelp
+
+// boolFlag is implemented by flag values that do not require a value, such as
+// bool flags and counters.
+// NOTE: Mirrors the interface in standard library.
+type boolFlag interface {
+	flag.Value
+	IsBoolFlag() bool
+}
+
+func isBoolFlag(v flag.Value) bool {
+	b, ok := v.(boolFlag)
+	return ok && b.IsBoolFlag()
+}
+
+// Parse parses flag definitions from the argument list, which should not
+// include the command name. Must be called after all flags in the FlagSet are
+// defined and before flags are accessed by the program. The return value will
+// be ErrHelp if -help or -h were set but not defined.
+func (fs *FlagSet[T]) Parse(arguments []string) error {
+	fs.parsed = true
+
+	_, err := fs.parseArgs(arguments)
+	if err == nil {
+		err = fs.resolve()
+	}
+	return fs.failed(err)
+}
+
+// resolve sets the flags not given in the arguments from the environment and
+// the config file, and checks the required flags and flag groups.
+func (fs *FlagSet[T]) resolve() error {
+	err := fs.parseSources()
+	if err == nil {
+		err = fs.checkRequired()
+	}
+	if err == nil {
+		err = fs.checkGroups()
+	}
+	return err
+}
+
+// parseSources sets the flags not given in the arguments from the environment
+// and the config file.
+func (fs *FlagSet[T]) parseSources() error {
+	err := fs.parseEnv()
+	if err == nil {
+		err = fs.parseConfig()
+	}
+	return err
+}
+
+// failed reports the parse error and shows the help usage, then exits or
+// panics according to the error handling of the flag set. A nil error is
+// returned as is.
+func (fs *FlagSet[T]) failed(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if err != ErrHelp {
+		fmt.Fprintln(fs.Output(), err)
+	}
+	if fs.Usage == nil {
+		usage(fs)
+	} else {
+		fs.Usage()
+	}
+
+	switch fs.ErrorHandling() {
+	case ExitOnError:
+		if err == ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// SetCombinedShorthands sets whether single dash arguments are parsed as
+// POSIX style combined shorthands. When enabled -xvf file is parsed as
+// -x -v -f file and the value of a shorthand can be attached to it, e.g. -n5
+// is parsed as -n 5. An argument matching a defined flag name, such as -name,
+// is still parsed as that flag.
+func (fs *FlagSet[T]) SetCombinedShorthands(enabled bool) {
+	fs.combinedShorthands = enabled
+}
+
+// SetStrictDashes sets whether the number of dashes is enforced when parsing.
+// When enabled flag names must be given with two dashes, e.g. --enable, and
+// shorthands with a single dash, e.g. -e. By default both forms are accepted
+// for names and shorthands alike.
+func (fs *FlagSet[T]) SetStrictDashes(enabled bool) {
+	fs.strictDashes = enabled
+}
+
+// SetInterspersed sets whether flags may appear after non-flag arguments.
+// Interspersed flags are enabled by default and then parsing continues until
+// the terminator "--". When disabled parsing stops at the first non-flag
+// argument like in the standard library.
+func (fs *FlagSet[T]) SetInterspersed(enabled bool) {
+	fs.interspersed = enabled
+}
+
+// Parsed reports whether fs.Parse has been called.
+func (fs *FlagSet[T]) Parsed() bool {
+	return fs.parsed
+}
+
+// Args returns the non-flag arguments in the order they were given.
+func (fs *FlagSet[T]) Args() []string {
+	return fs.args
+}
+
+// NArg is the number of arguments remaining after flags have been processed.
+func (fs *FlagSet[T]) NArg() int {
+	return len(fs.args)
+}
+
+// Arg returns the i'th argument. Arg(0) is the first remaining argument after
+// flags have been processed. Arg returns an empty string if the requested
+// element does not exist.
+func (fs *FlagSet[T]) Arg(i int) string {
+	if i < 0 || i >= len(fs.args) {
+		return ""
+	}
+	return fs.args[i]
+}
+
+// parseArgs parses the flags from the arguments. Parsing stops after the
+// terminator "--", or at the first non-flag argument if interspersed flags are
+// disabled. The non-flag arguments are collected to fs.args. parseArgs reports
+// whether parsing stopped at the terminator.
+func (fs *FlagSet[T]) parseArgs(arguments []string) (bool, error) {
+	var positionals []string
+	var terminated bool
+	fs.args = arguments
+
+	for len(fs.args) > 0 {
+		s := fs.args[0]
+		if len(s) < 2 || s[0] != '-' {
+			if !fs.interspersed {
+				break
+			}
+			positionals = append(positionals, s)
+			fs.args = fs.args[1:]
+			continue
+		}
+		fs.args = fs.args[1:]
+
+		dashes := "-"
+		if s[1] == '-' {
+			dashes = "--"
+			if len(s) == 2 {
+				terminated = true
+				break
+			}
+		}
+
+		name := s[len(dashes):]
+		if name[0] == '-' || name[0] == '=' {
+			return false, fmt.Errorf("bad flag syntax: %s", s)
+		}
+
+		if err := fs.parseFlag(dashes, name); err != nil {
+			return false, err
+		}
+	}
+
+	if len(positionals) > 0 {
+		fs.args = append(positionals, fs.args...)
+	}
+	return terminated, nil
+}
+
+// parseFlag parses a single flag argument without the leading dashes. The
+// value of the flag is read from the next argument, unless the value is given
+// with "=" or the flag does not require a value.
+func (fs *FlagSet[T]) parseFlag(dashes string, arg string) error {
+	name, value, hasValue := strings.Cut(arg, "=")
+
+	f := fs.Lookup(name)
+	if f == nil {
+		if p := fs.lookupPersistent(name); p != nil {
+			// The flag is parsed by the parent, taking its value from the
+			// arguments of this flag set
+			args := p.args
+			p.args = fs.args
+			err := p.parseFlag(dashes, arg)
+			fs.args, p.args = p.args, args
+			return err
+		}
+	}
+	if fs.combinedShorthands && dashes == "-" && len(name) > 1 && (f == nil || fs.strictDashes) {
+		return fs.parseShorthands(arg)
+	}
+	if f == nil {
+		if c := fs.lookupCounter(name); c != nil && !hasValue {
+			if fs.strictDashes && dashes != "-" {
+				return fmt.Errorf("flag %s%s must be given as -%s", dashes, name, name)
+			}
+			for range name {
+				if err := fs.set(dashes+name, c, "true"); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if f := fs.lookupNegated(name); f != nil {
+			if err := fs.checkDashes(dashes, name); err != nil {
+				return err
+			}
+			if hasValue {
+				return fmt.Errorf("flag %s%s does not take a value", dashes, name)
+			}
+			return fs.set(dashes+name, f, "false")
+		}
+		if name == "help" || name == "h" {
+			return ErrHelp
+		}
+		return fs.undefinedFlag(dashes, name)
+	}
+
+	if err := fs.checkDashes(dashes, name); err != nil {
+		return err
+	}
+
+	if !hasValue {
+		if isBoolFlag(f.Value) {
+			value = "true"
+		} else if info := fs.lookupInfo(name); info != nil && info.OptionalValue {
+			value = info.NoValue
+		} else {
+			if len(fs.args) == 0 {
+				return fmt.Errorf("flag needs an argument: %s%s", dashes, name)
+			}
+			value, fs.args = fs.args[0], fs.args[1:]
+		}
+	}
+
+	return fs.set(dashes+name, f, value)
+}
+
+// parseShorthands parses combined shorthands such as -xvf or -n5. Each
+// shorthand not requiring a value is set in turn, until a shorthand requiring
+// a value takes the rest of the argument, or the next argument, as its value.
+func (fs *FlagSet[T]) parseShorthands(arg string) error {
+	for i, r := range arg {
+		shorthand := string(r)
+		rest := arg[i+len(shorthand):]
+
+		owner, f := fs.untyped(), fs.Lookup(shorthand)
+		if f == nil {
+			if p := fs.lookupPersistent(shorthand); p != nil {
+				owner, f = p, p.Lookup(shorthand)
+			}
+		}
+		if f == nil {
+			if shorthand == "h" {
+				return ErrHelp
+			}
+			return fmt.Errorf("flag provided but not defined: -%s", shorthand)
+		}
+
+		if err := owner.checkDashes("-", shorthand); err != nil {
+			return err
+		}
+
+		if isBoolFlag(f.Value) {
+			if strings.HasPrefix(rest, "=") {
+				return owner.set("-"+shorthand, f, rest[1:])
+			}
+			if err := owner.set("-"+shorthand, f, "true"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if info := owner.lookupInfo(shorthand); rest == "" && info != nil && info.OptionalValue {
+			rest = info.NoValue
+		} else if rest == "" {
+			if len(fs.args) == 0 {
+				return fmt.Errorf("flag needs an argument: -%s", shorthand)
+			}
+			rest, fs.args = fs.args[0], fs.args[1:]
+		} else {
+			rest = strings.TrimPrefix(rest, "=")
+		}
+		return owner.set("-"+shorthand, f, rest)
+	}
+
+	return nil
+}
+
+// checkDashes checks that the flag was given with the right number of dashes
+// when strict dashes are enabled.
+func (fs *FlagSet[T]) checkDashes(dashes string, name string) error {
+	if !fs.strictDashes {
+		return nil
+	}
+
+	expected := "--"
+	if fs.isShorthand(name) {
+		expected = "-"
+	}
+	if dashes != expected {
+		return fmt.Errorf("flag %s%s must be given as %s%s", dashes, name, expected, name)
+	}
+	return nil
+}
+
+// lookupInfo returns the flag information of the flag with the given name or
+// shorthand, or nil if there is no such flag.
+func (fs *FlagSet[T]) lookupInfo(name string) *flagInfo {
+	for i, f := range fs.flags {
+		if f.Longhand == name || f.Shorthand == name {
+			return &fs.flags[i]
+		}
+	}
+	return nil
+}
+
+// isShorthand reports whether the name is defined as a shorthand.
+func (fs *FlagSet[T]) isShorthand(name string) bool {
+	for _, f := range fs.flags {
+		if f.Shorthand == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupNegated returns the negatable flag of a --no-<name> flag, or nil if
+// the name is not one.
+func (fs *FlagSet[T]) lookupNegated(name string) *flag.Flag {
+	if !strings.HasPrefix(name, "no-") {
+		return nil
+	}
+	name = strings.TrimPrefix(name, "no-")
+	for _, f := range fs.flags {
+		if f.Negatable && f.Longhand == name {
+			return fs.Lookup(name)
+		}
+	}
+	return nil
+}
+
+// undefinedFlag returns the error for a flag that is not defined, suggesting
+// the closest flag name if the name is likely a typo of it. Shorthands are
+// not suggested, as every shorthand is a single edit away from another.
+func (fs *FlagSet[T]) undefinedFlag(dashes string, name string) error {
+	err := fmt.Sprintf("flag provided but not defined: %s%s", dashes, name)
+	if len(name) < 2 {
+		return errors.New(err)
+	}
+
+	var names []string
+	add := func(f flagInfo) {
+		if len(f.Longhand) < 2 {
+			return
+		}
+		names = append(names, f.Longhand)
+		if f.Negatable {
+			names = append(names, "no-"+f.Longhand)
+		}
+	}
+	for _, f := range fs.flags {
+		add(f)
+	}
+	for p := fs.parent; p != nil; p = p.parent {
+		for _, f := range p.flags {
+			if f.Persistent {
+				add(f)
+			}
+		}
+	}
+
+	if suggestion := suggest(name, names); suggestion != "" {
+		err += ", did you mean --" + suggestion + "?"
+	}
+	return errors.New(err)
+}
+
+// lookupPersistent returns the closest parent flag set with a persistent flag
+// of the given name, or nil if there is no such flag set. The name may also be
+// the --no-<name> form of a negatable flag or a repeated counter shorthand.
+func (fs *FlagSet[T]) lookupPersistent(name string) *FlagSet[any] {
+	for p := fs.parent; p != nil; p = p.parent {
+		info := p.lookupInfo(name)
+		if info == nil && p.lookupNegated(name) != nil {
+			info = p.lookupInfo(strings.TrimPrefix(name, "no-"))
+		}
+		if info == nil && p.lookupCounter(name) != nil {
+			info = p.lookupInfo(name[:1])
+		}
+		if info != nil && info.Persistent {
+			return p
+		}
+	}
+	return nil
+}
+
+// lookupCounter returns the counter flag of a repeated counter shorthand such
+// as -vvv, or nil if the name is not one.
+func (fs *FlagSet[T]) lookupCounter(name string) *flag.Flag {
+	if strings.Count(name, name[:1]) != len(name) {
+		return nil
+	}
+
+	f := fs.Lookup(name[:1])
+	if f == nil {
+		return nil
+	}
+	if _, ok := f.Value.(*Counter); !ok {
+		return nil
+	}
+	return f
+}
+
+// set sets the value of the flag. arg is the flag as given in the arguments
+// and is used in the error message.
+func (fs *FlagSet[T]) set(arg string, f *flag.Flag, value string) error {
+	restore := fs.snapshot(f.Name)
+	if err := fs.FlagSet.Set(f.Name, value); err != nil {
+		return fmt.Errorf("invalid value %q for flag %s: %v", value, arg, err)
+	}
+	return fs.commit(f.Name, SourceCommandLine, restore)
+}
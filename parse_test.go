@@ -0,0 +1,429 @@
+package miniflag
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args            []string
+		notInterspersed bool
+		expected        []string
+		expectedBool    bool
+	}{
+		{
+			args:     []string{},
+			expected: []string{},
+		},
+		{
+			args:         []string{"-b", "-s", "value", "arg0"},
+			expected:     []string{"arg0"},
+			expectedBool: true,
+		},
+		{
+			args:     []string{"-s=-b", "--", "-b"},
+			expected: []string{"-b"},
+		},
+		{
+			args:         []string{"file.txt", "-b"},
+			expected:     []string{"file.txt"},
+			expectedBool: true,
+		},
+		{
+			args:         []string{"arg0", "--string=value", "arg1", "-s", "-1", "-b", "arg2"},
+			expected:     []string{"arg0", "arg1", "arg2"},
+			expectedBool: true,
+		},
+		{
+			args:     []string{"arg0", "--", "-b", "arg1"},
+			expected: []string{"arg0", "-b", "arg1"},
+		},
+		{
+			args:         []string{"-", "-b"},
+			expected:     []string{"-"},
+			expectedBool: true,
+		},
+		{
+			args:            []string{"-", "-b"},
+			notInterspersed: true,
+			expected:        []string{"-", "-b"},
+		},
+		{
+			args:            []string{"-b", "arg0", "--", "arg1"},
+			notInterspersed: true,
+			expected:        []string{"arg0", "--", "arg1"},
+			expectedBool:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetInterspersed(!tt.notInterspersed)
+		t.Run("", func(t *testing.T) {
+			b := SetFlag(fs, "bool", "b", false, "bool flag")
+			SetFlag(fs, "string", "s", "", "string flag")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if !fs.Parsed() {
+				t.Fatal("expected flag set to be parsed")
+			}
+
+			if tt.expectedBool != *b {
+				t.Fatalf("flag value did not match expected %t, got %t", tt.expectedBool, *b)
+			}
+
+			if !reflect.DeepEqual(tt.expected, fs.Args()) || fs.NArg() != len(tt.expected) {
+				t.Fatalf("args did not match expected %q, got %q", tt.expected, fs.Args())
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{"--undefined"},
+			expected: "flag provided but not defined: --undefined",
+		},
+		{
+			args:     []string{"--strnig", "value"},
+			expected: "flag provided but not defined: --strnig, did you mean --string?",
+		},
+		{
+			args:     []string{"-bool2"},
+			expected: "flag provided but not defined: -bool2, did you mean --bool?",
+		},
+		{
+			args:     []string{"-x"},
+			expected: "flag provided but not defined: -x",
+		},
+		{
+			args:     []string{"---s"},
+			expected: "bad flag syntax: ---s",
+		},
+		{
+			args:     []string{"-=s"},
+			expected: "bad flag syntax: -=s",
+		},
+		{
+			args:     []string{"-s"},
+			expected: "flag needs an argument: -s",
+		},
+		{
+			args:     []string{"--bool=yes"},
+			expected: `invalid value "yes" for flag --bool: parse error`,
+		},
+		{
+			args:     []string{"-h"},
+			expected: ErrHelp.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			SetFlag(fs, "bool", "b", false, "bool flag")
+			SetFlag(fs, "string", "s", "", "string flag")
+
+			err := fs.Parse(tt.args)
+			if err == nil || tt.expected != err.Error() {
+				t.Fatalf("error did not match expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestCombinedShorthands(t *testing.T) {
+	type result struct {
+		x, v    bool
+		file    string
+		n       int
+		verbose Counter
+		args    []string
+	}
+
+	tests := []struct {
+		args     []string
+		expected result
+		err      bool
+	}{
+		{
+			args:     []string{"-xvf", "file", "arg0"},
+			expected: result{x: true, v: true, file: "file", args: []string{"arg0"}},
+		},
+		{
+			args:     []string{"-n5"},
+			expected: result{n: 5, args: []string{}},
+		},
+		{
+			args:     []string{"-xn=5"},
+			expected: result{x: true, n: 5, args: []string{}},
+		},
+		{
+			args:     []string{"-xffile"},
+			expected: result{x: true, file: "file", args: []string{}},
+		},
+		{
+			args:     []string{"-VVVx"},
+			expected: result{x: true, verbose: 3, args: []string{}},
+		},
+		{
+			args:     []string{"-x=false", "-v"},
+			expected: result{v: true, args: []string{}},
+		},
+		{
+			args:     []string{"-number", "7"},
+			expected: result{n: 7, args: []string{}},
+		},
+		{
+			args: []string{"-xz"},
+			err:  true,
+		},
+		{
+			args: []string{"-xf"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.SetCombinedShorthands(true)
+		t.Run("", func(t *testing.T) {
+			x := SetFlag(fs, "x", "", false, "x flag")
+			v := SetFlag(fs, "v", "", false, "v flag")
+			file := SetFlag(fs, "file", "f", "", "file flag")
+			n := SetFlag(fs, "number", "n", 0, "number flag")
+			verbose := SetFlag(fs, "verbose", "V", Counter(0), "verbose flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected parse error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual := result{x: *x, v: *v, file: *file, n: *n, verbose: *verbose, args: fs.Args()}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Fatalf("flag values did not match expected %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestStrictDashes(t *testing.T) {
+	tests := []struct {
+		args     []string
+		combined bool
+		err      string
+	}{
+		{
+			args: []string{"--enable", "-e", "--name", "a", "-n", "b", "-vv"},
+		},
+		{
+			args: []string{"--enable=false", "--name=a"},
+		},
+		{
+			args: []string{"-enable"},
+			err:  "flag -enable must be given as --enable",
+		},
+		{
+			args: []string{"--e"},
+			err:  "flag --e must be given as -e",
+		},
+		{
+			args: []string{"-name=a"},
+			err:  "flag -name must be given as --name",
+		},
+		{
+			args: []string{"--vvv"},
+			err:  "flag --vvv must be given as -vvv",
+		},
+		{
+			args:     []string{"-evn", "a"},
+			combined: true,
+		},
+		{
+			args:     []string{"-ez"},
+			combined: true,
+			err:      "flag provided but not defined: -z",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.SetStrictDashes(true)
+		fs.SetCombinedShorthands(tt.combined)
+		t.Run("", func(t *testing.T) {
+			SetFlag(fs, "enable", "e", false, "enable flag")
+			SetFlag(fs, "name", "n", "", "name flag")
+			SetFlag(fs, "verbose", "v", Counter(0), "verbose flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || tt.err != err.Error() {
+				t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestNegatable(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected bool
+		err      string
+	}{
+		{
+			expected: true,
+		},
+		{
+			args:     []string{"--no-cache"},
+			expected: false,
+		},
+		{
+			args:     []string{"--no-cache", "--cache"},
+			expected: true,
+		},
+		{
+			args:     []string{"-c=false", "-no-cache", "-c"},
+			expected: true,
+		},
+		{
+			args: []string{"--no-cache=true"},
+			err:  "flag --no-cache does not take a value",
+		},
+		{
+			args: []string{"--no-color"},
+			err:  "flag provided but not defined: --no-color",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "cache", "c", true, "cache flag", Negatable())
+			SetFlag(fs, "color", "", true, "color flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err != "" {
+				if err == nil || tt.err != err.Error() {
+					t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %t, got %t", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestLookupNegated(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	SetFlag(fs, "cache", "c", true, "cache flag", Negatable())
+
+	if f := fs.lookupNegated("no-cache"); f == nil || f.Name != "cache" {
+		t.Fatalf("negated flag did not match expected cache, got %v", f)
+	}
+	for _, name := range []string{"cache", "c", "no-c"} {
+		if f := fs.lookupNegated(name); f != nil {
+			t.Fatalf("negated flag did not match expected nil for %s, got %v", name, f.Name)
+		}
+	}
+}
+
+func TestOptionalValue(t *testing.T) {
+	tests := []struct {
+		args         []string
+		combined     bool
+		expected     string
+		expectedArgs []string
+	}{
+		{
+			expected: "auto",
+		},
+		{
+			args:         []string{"--color"},
+			expected:     "always",
+			expectedArgs: []string{},
+		},
+		{
+			args:         []string{"--color", "never"},
+			expected:     "always",
+			expectedArgs: []string{"never"},
+		},
+		{
+			args:         []string{"--color=never", "file"},
+			expected:     "never",
+			expectedArgs: []string{"file"},
+		},
+		{
+			args:         []string{"-c", "file"},
+			expected:     "always",
+			expectedArgs: []string{"file"},
+		},
+		{
+			args:         []string{"-vc", "file"},
+			combined:     true,
+			expected:     "always",
+			expectedArgs: []string{"file"},
+		},
+		{
+			args:         []string{"-vcnever"},
+			combined:     true,
+			expected:     "never",
+			expectedArgs: []string{},
+		},
+		{
+			args:     []string{"--color="},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.SetCombinedShorthands(tt.combined)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "color", "c", "auto", "color flag", OptionalValue("always"))
+			SetFlag(fs, "verbose", "v", false, "verbose flag")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %q, got %q", tt.expected, *actual)
+			}
+
+			if tt.expectedArgs != nil && !reflect.DeepEqual(tt.expectedArgs, fs.Args()) {
+				t.Fatalf("args did not match expected %q, got %q", tt.expectedArgs, fs.Args())
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// types holds the parsers registered for all flag sets with RegisterType.
+var types = make(map[reflect.Type]any)
+
+// typeParser holds the registered parse and format functions of type T.
+type typeParser[T any] struct {
+	parse  func(string) (T, error)
+	format func(T) string
+}
+
+// RegisterType registers parse and format functions for type T in all flag
+// sets. After registration a value of type T can be used as the default value
+// in Flag and SetFlag. Registered types take precedence over the types
+// supported by miniflag. If format is nil the value is formatted with
+// fmt.Sprint.
+func RegisterType[T any](parse func(string) (T, error), format func(T) string) {
+	registerType(types, parse, format)
+}
+
+// RegisterFlagSetType registers parse and format functions for type T in the
+// given flag set. Types registered to a flag set take precedence over types
+// registered with RegisterType.
+func RegisterFlagSetType[T, C any](fs *FlagSet[C], parse func(string) (T, error), format func(T) string) {
+	if fs.types == nil {
+		fs.types = make(map[reflect.Type]any)
+	}
+	registerType(fs.types, parse, format)
+}
+
+func registerType[T any](m map[reflect.Type]any, parse func(string) (T, error), format func(T) string) {
+	if parse == nil {
+		panic(fmt.Sprintf("nil parse function registered for type %s", typeOf[T]()))
+	}
+	if format == nil {
+		format = formatValue[T]
+	}
+	m[typeOf[T]()] = typeParser[T]{parse: parse, format: format}
+}
+
+// anyParser is implemented by every typeParser. It parses and formats values
+// of a type only known at run time.
+type anyParser interface {
+	parseAny(s string) (any, error)
+	formatAny(v any) string
+}
+
+func (p typeParser[T]) parseAny(s string) (any, error) {
+	return p.parse(s)
+}
+
+func (p typeParser[T]) formatAny(v any) string {
+	t, _ := v.(T)
+	return p.format(t)
+}
+
+// lookupTypeOf returns the parser registered for type t, looking first from
+// the given flag set and then from the types registered for all flag sets.
+func lookupTypeOf(fs *FlagSet[any], t reflect.Type) (anyParser, bool) {
+	if p, ok := fs.types[t]; ok {
+		return p.(anyParser), true
+	}
+	if p, ok := types[t]; ok {
+		return p.(anyParser), true
+	}
+	return nil, false
+}
+
+// typeOf returns the reflection type of T. Unlike reflect.TypeOf it works
+// also for interface types.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,91 @@
+package miniflag
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type celsius float64
+
+func parseCelsius(s string) (celsius, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "C"), 64)
+	return celsius(v), err
+}
+
+func formatCelsius(c celsius) string {
+	return fmt.Sprintf("%gC", float64(c))
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType(parseCelsius, formatCelsius)
+
+	tests := []struct {
+		args     []string
+		expected celsius
+	}{
+		{
+			expected: 20,
+		},
+		{
+			args:     []string{"-t", "21.5C"},
+			expected: 21.5,
+		},
+		{
+			args:     []string{"--temp", "-4"},
+			expected: -4,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "temp", "t", celsius(20), "Test registered flag")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %v, got %v", tt.expected, *actual)
+			}
+
+			if s := fs.Lookup("temp").Value.String(); s != formatCelsius(tt.expected) {
+				t.Fatalf("flag string did not match expected %s, got %s", formatCelsius(tt.expected), s)
+			}
+		})
+	}
+}
+
+func TestRegisterFlagSetType(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	RegisterFlagSetType(fs, url.Parse, func(u *url.URL) string { return u.String() })
+
+	actual := SetFlag(fs, "url", "u", &url.URL{}, "Test flag set registered flag")
+
+	if err := fs.Parse([]string{"-u", "https://example.com/path"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if (*actual).Host != "example.com" {
+		t.Fatalf("flag value did not match expected host example.com, got %s", (*actual).Host)
+	}
+
+	other := NewFlagSet("", ContinueOnError)
+	if _, ok := lookupTypeOf(other.untyped(), typeOf[*url.URL]()); ok {
+		t.Fatal("type registered to a flag set leaked to other flag sets")
+	}
+}
+
+func TestUnsupportedType(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic for unsupported flag type")
+		}
+	}()
+
+	fs := NewFlagSet("", ContinueOnError)
+	SetFlag(fs, "unsupported", "", struct{}{}, "Test unsupported flag")
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"strings"
+)
+
+// RequiredFlagsError is the error returned by Parse when required flags are
+// not set. Flags holds the missing flags in the order they were defined, e.g.
+// --region.
+type RequiredFlagsError struct {
+	Flags []string
+}
+
+func (e *RequiredFlagsError) Error() string {
+	if len(e.Flags) == 1 {
+		return "missing required flag: " + e.Flags[0]
+	}
+	return "missing required flags: " + strings.Join(e.Flags, ", ")
+}
+
+// checkRequired returns a RequiredFlagsError listing every required flag that
+// has not been set from any source.
+func (fs *FlagSet[T]) checkRequired() error {
+	var missing []string
+	for _, info := range fs.flags {
+		if info.Required && !fs.Changed(info.Longhand) {
+			missing = append(missing, "--"+info.Longhand)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &RequiredFlagsError{Flags: missing}
+	}
+	return nil
+}
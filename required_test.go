@@ -0,0 +1,87 @@
+package miniflag
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	tests := []struct {
+		args     []string
+		env      map[string]string
+		expected []string
+	}{
+		{
+			expected: []string{"--region", "--bucket"},
+		},
+		{
+			args:     []string{"-r", "eu"},
+			expected: []string{"--bucket"},
+		},
+		{
+			args: []string{"--region", "", "--bucket", "data"},
+		},
+		{
+			args: []string{"--region", "eu"},
+			env:  map[string]string{"BUCKET": "data"},
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			SetFlag(fs, "region", "r", "", "region flag", Required())
+			SetFlag(fs, "bucket", "b", "", "bucket flag", Required(), Env("BUCKET"))
+			SetFlag(fs, "port", "p", 0, "port flag")
+
+			err := fs.Parse(tt.args)
+			if tt.expected == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var requiredErr *RequiredFlagsError
+			if !errors.As(err, &requiredErr) || !reflect.DeepEqual(tt.expected, requiredErr.Flags) {
+				t.Fatalf("missing flags did not match expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestRequiredError(t *testing.T) {
+	err := &RequiredFlagsError{Flags: []string{"--region", "--bucket"}}
+	if expected := "missing required flags: --region, --bucket"; expected != err.Error() {
+		t.Fatalf("error did not match expected %q, got %q", expected, err.Error())
+	}
+
+	err = &RequiredFlagsError{Flags: []string{"--region"}}
+	if expected := "missing required flag: --region"; expected != err.Error() {
+		t.Fatalf("error did not match expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestRequiredUsage(t *testing.T) {
+	var b bytes.Buffer
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&b)
+	SetFlag(fs, "region", "r", "", "Usage for `region`", Required())
+	SetFlag(fs, "port", "p", 0, "Usage for port")
+	fs.Usage()
+
+	expected := `usage: test -r --region=region [-p --port]
+    -r --region     Usage for ` + "`region`" + ` [required]
+    -p --port       Usage for port
+`
+	if actual := b.String(); expected != actual {
+		t.Fatalf("Help string did not match expected %q, got %q", expected, actual)
+	}
+}
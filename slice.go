@@ -0,0 +1,80 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sliceValue is a repeatable flag value that accumulates elements into a
+// slice. A single argument may hold multiple comma separated elements. The
+// default slice is replaced on the first Set instead of being appended to.
+type sliceValue[T any] struct {
+	value   *[]T
+	parse   func(string) (T, error)
+	changed bool
+}
+
+func (s *sliceValue[T]) Set(value string) error {
+	var elems []string
+	if value != "" {
+		elems = strings.Split(value, ",")
+	}
+	return s.add(elems)
+}
+
+// addElement adds a single element without splitting it on commas.
+func (s *sliceValue[T]) addElement(value string) error {
+	return s.add([]string{value})
+}
+
+func (s *sliceValue[T]) add(elems []string) error {
+	v := make([]T, 0, len(elems))
+	for _, e := range elems {
+		p, err := s.parse(e)
+		if err != nil {
+			return err
+		}
+		v = append(v, p)
+	}
+
+	if !s.changed {
+		*s.value = v
+		s.changed = true
+		return nil
+	}
+
+	*s.value = append(*s.value, v...)
+	return nil
+}
+
+func (s *sliceValue[T]) reset() {
+	s.changed = false
+}
+
+func (s *sliceValue[T]) Get() any {
+	return *s.value
+}
+
+func (s *sliceValue[T]) String() string {
+	// The flag package may call String on a zero value
+	if s.value == nil {
+		return "[]"
+	}
+
+	elems := make([]string, len(*s.value))
+	for i, e := range *s.value {
+		elems[i] = fmt.Sprint(e)
+	}
+	return "[" + strings.Join(elems, ",") + "]"
+}
+
+func sliceVar[T any](fs *FlagSet[any], p *[]T, name string, shorthand string, usage string, parse func(string) (T, error)) {
+	v := &sliceValue[T]{value: p, parse: parse}
+	fs.Var(v, name, usage)
+	if shorthand != "" {
+		fs.Var(v, shorthand, usage)
+	}
+}
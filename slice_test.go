@@ -0,0 +1,156 @@
+package miniflag
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStringSlice(t *testing.T) {
+	tests := []struct {
+		args     []string
+		value    []string
+		expected []string
+	}{
+		{
+			value:    []string{},
+			expected: []string{},
+		},
+		{
+			value:    []string{"default"},
+			expected: []string{"default"},
+		},
+		{
+			args:     []string{"-t", "a"},
+			value:    []string{"default"},
+			expected: []string{"a"},
+		},
+		{
+			args:     []string{"-t", "a", "--tag", "b"},
+			value:    []string{},
+			expected: []string{"a", "b"},
+		},
+		{
+			args:     []string{"-t", "a,b", "-t", "c"},
+			value:    []string{"default"},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			args:     []string{"--tag="},
+			value:    []string{"default"},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "tag", "t", tt.value, "Test string slice flag")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, *actual) {
+				t.Fatalf("flag value did not match expected %q, got %q", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestIntSlice(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []int
+		err      bool
+	}{
+		{
+			expected: []int{1},
+		},
+		{
+			args:     []string{"-i", "2", "-i", "0x10,-3"},
+			expected: []int{2, 16, -3},
+		},
+		{
+			args: []string{"-i", "1,a"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "int", "i", []int{1}, "Test int slice flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected parse error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, *actual) {
+				t.Fatalf("flag value did not match expected %v, got %v", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestDurationSlice(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []time.Duration
+	}{
+		{
+			expected: []time.Duration{},
+		},
+		{
+			args:     []string{"-d", "1s,2ms", "--duration", "3ns"},
+			expected: []time.Duration{time.Second, 2 * time.Millisecond, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "duration", "d", []time.Duration{}, "Test duration slice flag")
+
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, *actual) {
+				t.Fatalf("flag value did not match expected %v, got %v", tt.expected, *actual)
+			}
+		})
+	}
+}
+
+func TestSliceString(t *testing.T) {
+	tests := []struct {
+		value    sliceValue[bool]
+		expected string
+	}{
+		{
+			expected: "[]",
+		},
+		{
+			value:    sliceValue[bool]{value: &[]bool{true, false}},
+			expected: "[true,false]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			if actual := tt.value.String(); tt.expected != actual {
+				t.Fatalf("slice string did not match expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+// Source describes where the value of a flag came from.
+type Source int
+
+// These constants are returned by FlagSet.Source.
+const (
+	SourceDefault     Source = iota // The flag has its default value.
+	SourceCommandLine               // The flag was given in the arguments.
+	SourceEnv                       // The flag was read from an environment variable.
+	SourceConfig                    // The flag was read from the config file.
+	SourceSet                       // The flag was set with FlagSet.Set.
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceCommandLine:
+		return "command line"
+	case SourceEnv:
+		return "environment"
+	case SourceConfig:
+		return "config file"
+	case SourceSet:
+		return "set"
+	}
+	return "default"
+}
+
+// Source returns where the value of the flag with the given name or shorthand
+// came from. Flags that have not been set, as well as undefined flags, report
+// SourceDefault. A flag given explicitly with its default value reports the
+// source it was given in.
+func (fs *FlagSet[T]) Source(name string) Source {
+	if info := fs.lookupInfo(name); info != nil {
+		return fs.sources[info.Longhand]
+	}
+	return SourceDefault
+}
+
+// Changed reports whether the flag with the given name or shorthand has been
+// set from any source other than its default value.
+func (fs *FlagSet[T]) Changed(name string) bool {
+	return fs.Source(name) != SourceDefault
+}
+
+// Set sets the value of the named flag and records SourceSet as its source.
+// The value is validated like values given on the command line, and a rejected
+// value leaves the flag unchanged.
+func (fs *FlagSet[T]) Set(name string, value string) error {
+	restore := fs.snapshot(name)
+	if err := fs.FlagSet.Set(name, value); err != nil {
+		return err
+	}
+	return fs.commit(name, SourceSet, restore)
+}
+
+// setSource records the source of the flag with the given name or shorthand.
+func (fs *FlagSet[T]) setSource(name string, source Source) {
+	info := fs.lookupInfo(name)
+	if info == nil {
+		return
+	}
+	if fs.sources == nil {
+		fs.sources = make(map[string]Source)
+	}
+	fs.sources[info.Longhand] = source
+}
@@ -0,0 +1,67 @@
+package miniflag
+
+import (
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	path := writeConfig(t, "config.toml", `
+name = "config"
+level = "debug"
+`)
+	t.Setenv("PORT", "4000")
+
+	fs := NewFlagSet("", ContinueOnError)
+	fs.SetConfigFile(path)
+	SetFlag(fs, "name", "n", "default", "name flag")
+	SetFlag(fs, "port", "p", 0, "port flag", Env("PORT"))
+	SetFlag(fs, "level", "l", "info", "level flag")
+	SetFlag(fs, "debug", "d", false, "debug flag")
+	SetFlag(fs, "addr", "a", "localhost", "addr flag")
+	SetFlag(fs, "user", "u", "", "user flag")
+
+	if err := fs.Set("user", "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.Parse([]string{"-n", "default", "--debug"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		expected Source
+	}{
+		{name: "name", expected: SourceCommandLine},
+		{name: "n", expected: SourceCommandLine},
+		{name: "debug", expected: SourceCommandLine},
+		{name: "port", expected: SourceEnv},
+		{name: "level", expected: SourceConfig},
+		{name: "user", expected: SourceSet},
+		{name: "addr", expected: SourceDefault},
+		{name: "undefined", expected: SourceDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := fs.Source(tt.name); tt.expected != actual {
+				t.Fatalf("source did not match expected %s, got %s", tt.expected, actual)
+			}
+			if changed := tt.expected != SourceDefault; changed != fs.Changed(tt.name) {
+				t.Fatalf("changed did not match expected %t, got %t", changed, fs.Changed(tt.name))
+			}
+		})
+	}
+}
+
+func TestSetError(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	SetFlag(fs, "port", "p", 0, "port flag")
+
+	if err := fs.Set("port", "http"); err == nil {
+		t.Fatal("expected error for invalid value")
+	}
+	if fs.Changed("port") {
+		t.Fatal("expected flag to be unchanged after invalid value")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+// suggest returns the candidate closest to s, or an empty string if no
+// candidate is close enough to be a likely typo of s.
+func suggest(s string, candidates []string) string {
+	best, bestDistance := "", len(s)/3
+	if bestDistance < 1 {
+		bestDistance = 1
+	}
+
+	for _, c := range candidates {
+		if d := editDistance(s, c); d <= bestDistance && (best == "" || d < bestDistance) {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the optimal string alignment distance between a and b,
+// the Levenshtein distance in which swapping two adjacent characters, a common
+// typo, counts as a single edit.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// prev2, prev and curr hold the distances of the last three rows
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < curr[j] {
+				curr[j] = d
+			}
+			if d := curr[j-1] + 1; d < curr[j] {
+				curr[j] = d
+			}
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if d := prev2[j-2] + 1; d < curr[j] {
+					curr[j] = d
+				}
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(rb)]
+}
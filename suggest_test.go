@@ -0,0 +1,52 @@
+package miniflag
+
+import (
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"json", "yaml", "text", "verbose", "sub", "list"}
+
+	tests := []struct {
+		s        string
+		expected string
+	}{
+		{s: "jsn", expected: "json"},
+		{s: "yml", expected: "yaml"},
+		{s: "verbos", expected: "verbose"},
+		{s: "vrebose", expected: "verbose"},
+		{s: "sjon", expected: "json"},
+		{s: "sbu", expected: "sub"},
+		{s: "lsit", expected: "list"},
+		{s: "xml", expected: ""},
+		{s: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if actual := suggest(tt.s, candidates); tt.expected != actual {
+				t.Fatalf("suggestion did not match expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "abc", b: "", expected: 3},
+		{a: "kitten", b: "sitting", expected: 3},
+		{a: "json", b: "jsno", expected: 1},
+		{a: "sbu", b: "sub", expected: 1},
+		{a: "ca", b: "abc", expected: 3},
+	}
+
+	for _, tt := range tests {
+		if actual := editDistance(tt.a, tt.b); tt.expected != actual {
+			t.Fatalf("distance between %q and %q did not match expected %d, got %d", tt.a, tt.b, tt.expected, actual)
+		}
+	}
+}
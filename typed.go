@@ -0,0 +1,36 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+// NewTypedFlagSet returns a new flag set with the specified name and error
+// handling property, and a flag defined for each field of the struct type T
+// as in Bind. The parsed flags are stored in the value of the flag set, which
+// is returned by ParseValue and Value. Further flags can be defined with
+// SetFlag. NewTypedFlagSet panics if T is not a struct type.
+func NewTypedFlagSet[T any](name string, errorHandling ErrorHandling) *FlagSet[T] {
+	value := new(T)
+	fs := NewFlagSet(name, errorHandling)
+	Bind(fs, value)
+	fs.value = value
+	return (*FlagSet[T])(fs)
+}
+
+// ParseValue parses the argument list like Parse and returns the value of a
+// typed flag set populated from the flags.
+func (fs *FlagSet[T]) ParseValue(arguments []string) (T, error) {
+	err := fs.Parse(arguments)
+
+	var value T
+	if p := fs.Value(); p != nil {
+		value = *p
+	}
+	return value, err
+}
+
+// Value returns the pointer to the value of a typed flag set, or nil if the
+// flag set was not created with NewTypedFlagSet.
+func (fs *FlagSet[T]) Value() *T {
+	value, _ := fs.value.(*T)
+	return value
+}
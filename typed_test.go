@@ -0,0 +1,72 @@
+package miniflag
+
+import (
+	"io"
+	"testing"
+)
+
+type typedConfig struct {
+	Name    string `flag:"name,n" default:"server"`
+	Port    int    `flag:"port,p" default:"8080"`
+	Verbose bool   `flag:"verbose,v"`
+}
+
+func TestTypedFlagSet(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected typedConfig
+		err      bool
+	}{
+		{
+			expected: typedConfig{Name: "server", Port: 8080},
+		},
+		{
+			args:     []string{"-n", "api", "--port", "9090", "-v"},
+			expected: typedConfig{Name: "api", Port: 9090, Verbose: true},
+		},
+		{
+			args: []string{"--port", "http"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewTypedFlagSet[typedConfig]("typed", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual, err := fs.ParseValue(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected parse error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != actual || tt.expected != *fs.Value() {
+				t.Fatalf("value did not match expected %+v, got %+v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestTypedFlagSetFlag(t *testing.T) {
+	fs := NewTypedFlagSet[typedConfig]("typed", ContinueOnError)
+	debug := SetFlag(fs, "debug", "d", false, "debug flag")
+
+	if _, err := fs.ParseValue([]string{"-d"}); err != nil {
+		t.Fatal(err)
+	}
+	if !*debug {
+		t.Fatal("flag value did not match expected true, got false")
+	}
+}
+
+func TestUntypedValue(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	if fs.Value() != nil {
+		t.Fatalf("value did not match expected nil, got %v", fs.Value())
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// number is the set of numeric types supported by Range.
+type number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// ValidationError is the error returned by Parse and Set when the value of a
+// flag is rejected by one of its validators. Flag is the name of the flag,
+// e.g. --port, and Source where the value came from. Env is the environment
+// variable of values from SourceEnv. The rejected value is not stored, the
+// flag keeps its previous value.
+type ValidationError struct {
+	Flag   string
+	Source Source
+	Env    string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	switch e.Source {
+	case SourceEnv:
+		return fmt.Sprintf("%s from environment variable %s: %v", e.Flag, e.Env, e.Err)
+	case SourceConfig:
+		return fmt.Sprintf("%s from %s: %v", e.Flag, e.Source, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Flag, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// validator holds a validation rule for values of type typ.
+type validator struct {
+	typ      reflect.Type
+	validate func(any) error
+	// choices are the allowed values of the Choices validator
+	choices []string
+}
+
+func newValidator[T any](validate func(T) error) validator {
+	return validator{
+		typ: typeOf[T](),
+		validate: func(v any) error {
+			t, _ := v.(T)
+			return validate(t)
+		},
+	}
+}
+
+// Range validates that the value of a numeric flag is between min and max,
+// inclusive.
+func Range[T number](min T, max T) Option {
+	return func(cfg *flagConfig) {
+		cfg.validators = append(cfg.validators, newValidator(func(v T) error {
+			if v < min || v > max {
+				return fmt.Errorf("%v out of range [%v,%v]", v, min, max)
+			}
+			return nil
+		}))
+	}
+}
+
+// Pattern validates that the value of a string flag matches the regular
+// expression expr. Pattern panics if expr cannot be compiled.
+func Pattern(expr string) Option {
+	re := regexp.MustCompile(expr)
+	return func(cfg *flagConfig) {
+		cfg.validators = append(cfg.validators, newValidator(func(v string) error {
+			if !re.MatchString(v) {
+				return fmt.Errorf("%q does not match %s", v, expr)
+			}
+			return nil
+		}))
+	}
+}
+
+// Choices validates that the value of the flag is one of the given choices.
+// The choices are shown in the help usage in place of the usage value and
+// returned by FlagSet.Choices.
+func Choices[T comparable](choices ...T) Option {
+	names := make([]string, len(choices))
+	for i, c := range choices {
+		names[i] = fmt.Sprint(c)
+	}
+
+	return func(cfg *flagConfig) {
+		v := newValidator(func(v T) error {
+			for _, c := range choices {
+				if v == c {
+					return nil
+				}
+			}
+			return fmt.Errorf("%v is not one of %s", v, strings.Join(names, ", "))
+		})
+		v.choices = names
+		cfg.validators = append(cfg.validators, v)
+	}
+}
+
+// Validate validates the value of the flag with the given function. The
+// function returns an error describing why the value is not valid.
+func Validate[T any](fn func(T) error) Option {
+	return func(cfg *flagConfig) {
+		cfg.validators = append(cfg.validators, newValidator(fn))
+	}
+}
+
+// Choices returns the allowed values of the flag with the given name or
+// shorthand, for example to complete the flag in a shell. Choices returns nil
+// if the values of the flag are not restricted with the Choices option or by
+// an EnumValue.
+func (fs *FlagSet[T]) Choices(name string) []string {
+	if info := fs.lookupInfo(name); info != nil {
+		return fs.choices[info.Longhand]
+	}
+	return nil
+}
+
+// addValidators adds the validators of the flag with the given addressable
+// value. Validators of the element type of a slice flag validate each
+// element. addValidators panics if a validator does not apply to the type of
+// the value.
+func (fs *FlagSet[T]) addValidators(name string, value reflect.Value, validators []validator) {
+	if len(validators) == 0 {
+		return
+	}
+
+	if fs.validated == nil {
+		fs.validated = make(map[string]reflect.Value)
+	}
+	fs.validated[name] = value
+
+	typ, get := value.Type(), value.Interface
+	for _, v := range validators {
+		v := v
+
+		check := func() error {
+			return v.validate(get())
+		}
+		if v.typ != typ {
+			if typ.Kind() != reflect.Slice || typ.Elem() != v.typ {
+				panic(fmt.Sprintf("validator for %s cannot be used with flag %s of type %s", v.typ, name, typ))
+			}
+			check = func() error {
+				elems := reflect.ValueOf(get())
+				for i := 0; i < elems.Len(); i++ {
+					if err := v.validate(elems.Index(i).Interface()); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+		}
+
+		if fs.validators == nil {
+			fs.validators = make(map[string][]func() error)
+		}
+		fs.validators[name] = append(fs.validators[name], check)
+
+		if v.choices != nil {
+			fs.addChoices(name, v.choices)
+		}
+	}
+}
+
+// addChoices records the allowed values of the flag. The help usage shows the
+// values in place of the usage value, e.g. --format=text|json.
+func (fs *FlagSet[T]) addChoices(name string, choices []string) {
+	if fs.choices == nil {
+		fs.choices = make(map[string][]string)
+	}
+	fs.choices[name] = append(fs.choices[name], choices...)
+
+	if info := fs.lookupInfo(name); info != nil {
+		info.UsageValue = strings.Join(fs.choices[name], "|")
+	}
+}
+
+// snapshot returns a function restoring the current value of the flag with
+// the given name or shorthand. Only the values of flags with validators are
+// restored, since they are the only values rejected after being set.
+func (fs *FlagSet[T]) snapshot(name string) func() {
+	info := fs.lookupInfo(name)
+	if info == nil {
+		return func() {}
+	}
+	value, ok := fs.validated[info.Longhand]
+	if !ok {
+		return func() {}
+	}
+
+	old := reflect.New(value.Type()).Elem()
+	old.Set(value)
+	// Maps are modified in place when they are set, so the entries are copied
+	if value.Kind() == reflect.Map && !value.IsNil() {
+		old.Set(reflect.MakeMapWithSize(value.Type(), value.Len()))
+		iter := value.MapRange()
+		for iter.Next() {
+			old.SetMapIndex(iter.Key(), iter.Value())
+		}
+	}
+	changed := fs.Changed(name)
+
+	return func() {
+		value.Set(old)
+		// Slice and map values replace the default value again on the next Set
+		if f := fs.Lookup(info.Longhand); f != nil && !changed {
+			if r, ok := f.Value.(resetter); ok {
+				r.reset()
+			}
+		}
+	}
+}
+
+// commit validates the value set to the flag with the given name or
+// shorthand, and records the source of the value. A rejected value is
+// replaced with the previous value of the flag with restore, which is
+// returned by snapshot before setting the value.
+func (fs *FlagSet[T]) commit(name string, source Source, restore func()) error {
+	info := fs.lookupInfo(name)
+	if info == nil {
+		return nil
+	}
+
+	for _, check := range fs.validators[info.Longhand] {
+		if err := check(); err != nil {
+			restore()
+			err := &ValidationError{Flag: "--" + info.Longhand, Source: source, Err: err}
+			if source == SourceEnv {
+				err.Env = fs.envName(*info)
+			}
+			return err
+		}
+	}
+
+	fs.setSource(name, source)
+	return nil
+}
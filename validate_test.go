@@ -0,0 +1,214 @@
+package miniflag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		args []string
+		env  map[string]string
+		err  string
+	}{
+		{
+			args: []string{"--port", "443", "--name", "api", "--format", "json", "--tag", "a,b", "--even", "2"},
+		},
+		{
+			args: []string{"--port", "70000"},
+			err:  "--port: 70000 out of range [1,65535]",
+		},
+		{
+			args: []string{"-p", "0"},
+			err:  "--port: 0 out of range [1,65535]",
+		},
+		{
+			env: map[string]string{"PORT": "70000"},
+			err: "--port from environment variable PORT: 70000 out of range [1,65535]",
+		},
+		{
+			args: []string{"--name", "API"},
+			err:  `--name: "API" does not match ^[a-z]+$`,
+		},
+		{
+			args: []string{"--format", "xml"},
+			err:  "--format: xml is not one of text, json, yaml",
+		},
+		{
+			args: []string{"--tag", "a,ab"},
+			err:  "--tag: ab is not one of a, b",
+		},
+		{
+			args: []string{"--even", "3"},
+			err:  "--even: 3 is odd",
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			SetFlag(fs, "port", "p", 80, "port flag", Range(1, 65535), Env("PORT"))
+			SetFlag(fs, "name", "n", "", "name flag", Pattern("^[a-z]+$"))
+			SetFlag(fs, "format", "f", "text", "format flag", Choices("text", "json", "yaml"))
+			SetFlag(fs, "tag", "t", []string{}, "tag flag", Choices("a", "b"))
+			SetFlag(fs, "even", "e", 0, "even flag", Validate(func(v int) error {
+				if v%2 != 0 {
+					return fmt.Errorf("%d is odd", v)
+				}
+				return nil
+			}))
+
+			err := fs.Parse(tt.args)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) || tt.err != err.Error() {
+				t.Fatalf("error did not match expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateSet(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	port := SetFlag(fs, "port", "p", 80, "port flag", Range(1, 65535))
+
+	expected := "--port: 0 out of range [1,65535]"
+	if err := fs.Set("port", "0"); err == nil || expected != err.Error() {
+		t.Fatalf("error did not match expected %q, got %v", expected, err)
+	}
+	if fs.Changed("port") {
+		t.Fatal("expected flag to be unchanged after invalid value")
+	}
+	if *port != 80 {
+		t.Fatalf("flag value did not match expected 80, got %d", *port)
+	}
+
+	tags := SetFlag(fs, "tag", "t", []string{"a"}, "tag flag", Choices("a", "b"))
+	if err := fs.Set("tag", "ab"); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if err := fs.Set("tag", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"b"}; !reflect.DeepEqual(expected, *tags) {
+		t.Fatalf("flag value did not match expected %q, got %q", expected, *tags)
+	}
+}
+
+func TestValidateRestore(t *testing.T) {
+	tests := []struct {
+		args   []string
+		port   int
+		tags   []string
+		labels map[string]string
+	}{
+		{
+			args:   []string{"--port", "70000"},
+			port:   80,
+			tags:   []string{"a"},
+			labels: map[string]string{"env": "dev"},
+		},
+		{
+			args:   []string{"--port", "443", "--port", "0"},
+			port:   443,
+			tags:   []string{"a"},
+			labels: map[string]string{"env": "dev"},
+		},
+		{
+			args:   []string{"--tag", "ab"},
+			port:   80,
+			tags:   []string{"a"},
+			labels: map[string]string{"env": "dev"},
+		},
+		{
+			args:   []string{"--tag", "b", "--tag", "ab"},
+			port:   80,
+			tags:   []string{"b"},
+			labels: map[string]string{"env": "dev"},
+		},
+		{
+			args:   []string{"--label", "env=prod", "--label", "team=ops"},
+			port:   80,
+			tags:   []string{"a"},
+			labels: map[string]string{"env": "prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		port := SetFlag(fs, "port", "p", 80, "port flag", Range(1, 65535))
+		tags := SetFlag(fs, "tag", "t", []string{"a"}, "tag flag", Choices("a", "b"))
+		labels := SetFlag(fs, "label", "l", map[string]string{"env": "dev"}, "label flag", Validate(func(v map[string]string) error {
+			if len(v) > 1 {
+				return errors.New("too many labels")
+			}
+			return nil
+		}))
+		t.Run("", func(t *testing.T) {
+			if err := fs.Parse(tt.args); err == nil {
+				t.Fatal("expected validation error")
+			}
+			if *port != tt.port {
+				t.Fatalf("flag value did not match expected %d, got %d", tt.port, *port)
+			}
+			if !reflect.DeepEqual(tt.tags, *tags) {
+				t.Fatalf("flag value did not match expected %q, got %q", tt.tags, *tags)
+			}
+			if !reflect.DeepEqual(tt.labels, *labels) {
+				t.Fatalf("flag value did not match expected %q, got %q", tt.labels, *labels)
+			}
+		})
+	}
+}
+
+func TestValidatorType(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+
+	defer func() {
+		r := recover()
+		if r == nil || !strings.Contains(fmt.Sprint(r), "cannot be used with flag port") {
+			t.Fatalf("expected panic for validator type, got %v", r)
+		}
+	}()
+	SetFlag(fs, "port", "p", int64(80), "port flag", Range(1, 65535))
+}
+
+func TestChoices(t *testing.T) {
+	var b bytes.Buffer
+	fs := NewFlagSet("test", ContinueOnError)
+	fs.SetOutput(&b)
+	SetFlag(fs, "format", "f", "text", "Usage for format", Choices("text", "json"))
+	SetFlag(fs, "port", "p", 80, "Usage for port")
+
+	if expected := []string{"text", "json"}; !reflect.DeepEqual(expected, fs.Choices("f")) {
+		t.Fatalf("choices did not match expected %q, got %q", expected, fs.Choices("f"))
+	}
+	if fs.Choices("port") != nil {
+		t.Fatalf("choices did not match expected nil, got %q", fs.Choices("port"))
+	}
+
+	fs.Usage()
+	expected := `usage: test [-f --format=text|json] [-p --port]
+    -f --format     Usage for format
+    -p --port       Usage for port
+`
+	if actual := b.String(); expected != actual {
+		t.Fatalf("Help string did not match expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2022 Erik Kinnunen.
+// license can be found in the LICENSE file.
+
+package miniflag
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// errParse is returned by Set if a flag's value fails to parse, such as with
+// an invalid integer. The flag set wraps it with the flag name and value.
+// NOTE: Mirrors the error in standard library.
+var errParse = errors.New("parse error")
+
+// errRange is returned by Set if a flag's value is out of range. The flag set
+// wraps it with the flag name and value.
+// NOTE: Mirrors the error in standard library.
+var errRange = errors.New("value out of range")
+
+// numError converts strconv errors to the same errors the standard library
+// flag values return.
+func numError(err error) error {
+	ne, ok := err.(*strconv.NumError)
+	if !ok {
+		return err
+	}
+	if ne.Err == strconv.ErrSyntax {
+		return errParse
+	}
+	if ne.Err == strconv.ErrRange {
+		return errRange
+	}
+	return err
+}
+
+// Parsers for the element types of slice and map flags. The accepted syntax matches
+// the corresponding flag types in the standard library.
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+func parseBool(s string) (bool, error) {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, errParse
+	}
+	return v, nil
+}
+
+func parseInt(s string) (int, error) {
+	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	return int(v), numError(err)
+}
+
+func parseInt64(s string) (int64, error) {
+	v, err := strconv.ParseInt(s, 0, 64)
+	return v, numError(err)
+}
+
+func parseUint(s string) (uint, error) {
+	v, err := strconv.ParseUint(s, 0, strconv.IntSize)
+	return uint(v), numError(err)
+}
+
+func parseFloat64(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	return v, numError(err)
+}
+
+// Parsers for the sized numeric types that have no counterpart in the
+// standard library. Integers accept the base prefixes of strconv.ParseInt and
+// report the type in range errors.
+
+func parseIntN[T int8 | int16 | int32](bits int) func(string) (T, error) {
+	return func(s string) (T, error) {
+		v, err := strconv.ParseInt(s, 0, bits)
+		return T(v), sizedNumError[T](s, err)
+	}
+}
+
+func parseUintN[T uint8 | uint16 | uint32](bits int) func(string) (T, error) {
+	return func(s string) (T, error) {
+		v, err := strconv.ParseUint(s, 0, bits)
+		return T(v), sizedNumError[T](s, err)
+	}
+}
+
+func parseFloat32(s string) (float32, error) {
+	v, err := strconv.ParseFloat(s, 32)
+	return float32(v), sizedNumError[float32](s, err)
+}
+
+func parseComplex128(s string) (complex128, error) {
+	v, err := strconv.ParseComplex(s, 128)
+	return v, sizedNumError[complex128](s, err)
+}
+
+// sizedNumError is like numError, but names the type of the value in range
+// errors.
+func sizedNumError[T any](s string, err error) error {
+	if err = numError(err); err == errRange {
+		return fmt.Errorf("value %s out of range for %s", s, typeOf[T]())
+	}
+	return err
+}
+
+// formatValue formats v in the default format.
+func formatValue[T any](v T) string {
+	return fmt.Sprint(v)
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errParse
+	}
+	return v, nil
+}
+
+// funcValue is a flag value that parses and formats its value with the given
+// functions.
+type funcValue[T any] struct {
+	value  *T
+	parse  func(string) (T, error)
+	format func(T) string
+}
+
+func (f *funcValue[T]) Set(s string) error {
+	v, err := f.parse(s)
+	if err != nil {
+		return err
+	}
+	*f.value = v
+	return nil
+}
+
+func (f *funcValue[T]) Get() any {
+	return *f.value
+}
+
+func (f *funcValue[T]) String() string {
+	// The flag package may call String on a zero value
+	if f.value == nil {
+		return ""
+	}
+	return f.format(*f.value)
+}
+
+func funcVar[T any](fs *FlagSet[any], p *T, name string, shorthand string, usage string, parse func(string) (T, error), format func(T) string) {
+	v := &funcValue[T]{value: p, parse: parse, format: format}
+	fs.Var(v, name, usage)
+	if shorthand != "" {
+		fs.Var(v, shorthand, usage)
+	}
+}
+
+// reflectValue is a flag value of a type only known at run time. The value is
+// stored in an addressable reflection value, such as a struct field.
+type reflectValue struct {
+	value  reflect.Value
+	parse  func(string) (any, error)
+	format func(reflect.Value) string
+}
+
+func (r *reflectValue) Set(s string) error {
+	v, err := r.parse(s)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		r.value.Set(reflect.Zero(r.value.Type()))
+	} else {
+		r.value.Set(reflect.ValueOf(v))
+	}
+	return nil
+}
+
+func (r *reflectValue) Get() any {
+	return r.value.Interface()
+}
+
+func (r *reflectValue) String() string {
+	// The flag package may call String on a zero value
+	if !r.value.IsValid() {
+		return ""
+	}
+	return r.format(r.value)
+}
+
+func reflectVar(fs *FlagSet[any], rv reflect.Value, name string, shorthand string, usage string, parse func(string) (any, error), format func(reflect.Value) string) {
+	v := &reflectValue{value: rv, parse: parse, format: format}
+	fs.Var(v, name, usage)
+	if shorthand != "" {
+		fs.Var(v, shorthand, usage)
+	}
+}
+
+// parseText returns a function parsing values of type t with the
+// UnmarshalText method of *t.
+func parseText(t reflect.Type) func(string) (any, error) {
+	return func(s string) (any, error) {
+		p := reflect.New(t)
+		err := p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		return p.Elem().Interface(), err
+	}
+}
+
+// formatText formats the addressable value rv with the MarshalText method of
+// its type or pointer type, falling back to the default format of the value.
+func formatText(rv reflect.Value) string {
+	m, ok := rv.Addr().Interface().(encoding.TextMarshaler)
+	if !ok {
+		return fmt.Sprint(rv.Interface())
+	}
+	b, err := m.MarshalText()
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
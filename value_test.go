@@ -0,0 +1,98 @@
+package miniflag
+
+import (
+	"io"
+	"math/big"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestTextAddr(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected netip.Addr
+		err      bool
+	}{
+		{
+			expected: netip.MustParseAddr("127.0.0.1"),
+		},
+		{
+			args:     []string{"-a", "10.0.0.1"},
+			expected: netip.MustParseAddr("10.0.0.1"),
+		},
+		{
+			args:     []string{"--addr", "::1"},
+			expected: netip.MustParseAddr("::1"),
+		},
+		{
+			args: []string{"--addr", "localhost"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		fs := NewFlagSet("", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		t.Run("", func(t *testing.T) {
+			actual := SetFlag(fs, "addr", "a", netip.MustParseAddr("127.0.0.1"), "Test netip.Addr flag")
+
+			err := fs.Parse(tt.args)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected parse error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.expected != *actual {
+				t.Fatalf("flag value did not match expected %s, got %s", tt.expected, *actual)
+			}
+
+			if s := fs.Lookup("addr").Value.String(); s != tt.expected.String() {
+				t.Fatalf("flag string did not match expected %s, got %s", tt.expected, s)
+			}
+		})
+	}
+}
+
+func TestTextBigInt(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	actual := SetFlag(fs, "big", "b", big.Int{}, "Test big.Int flag")
+
+	n := strings.Repeat("9", 40)
+	if err := fs.Parse([]string{"-b", n}); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual.String() != n {
+		t.Fatalf("flag value did not match expected %s, got %s", n, actual)
+	}
+}
+
+type textID struct {
+	prefix string
+	n      string
+}
+
+func (id *textID) UnmarshalText(b []byte) error {
+	id.prefix, id.n, _ = strings.Cut(string(b), "-")
+	return nil
+}
+
+func TestTextUnmarshalerOnly(t *testing.T) {
+	fs := NewFlagSet("", ContinueOnError)
+	actual := SetFlag(fs, "id", "", textID{}, "Test encoding.TextUnmarshaler flag")
+
+	if err := fs.Parse([]string{"--id", "usr-42"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := textID{prefix: "usr", n: "42"}
+	if expected != *actual {
+		t.Fatalf("flag value did not match expected %v, got %v", expected, *actual)
+	}
+}